@@ -0,0 +1,241 @@
+package m3u8
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedAttributeField is returned when a struct field cannot be mapped to an attribute.
+var ErrUnsupportedAttributeField = errors.New("unsupported attribute field")
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	resolutionType = reflect.TypeOf(Resolution{})
+)
+
+// attributeField represents a struct field with the m3u8 struct tag.
+type attributeField struct {
+	index     int
+	name      string
+	quoted    bool
+	omitEmpty bool
+}
+
+// attributeFields returns the fields of the struct type which have the m3u8 struct tag.
+// The tag is `m3u8:"NAME"` optionally followed by the options "quoted" and "omitempty".
+func attributeFields(typ reflect.Type) []attributeField {
+	fields := make([]attributeField, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag, ok := sf.Tag.Lookup("m3u8")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		field := attributeField{index: i, name: parts[0]}
+		if field.name == "" {
+			field.name = sf.Name
+		}
+		for _, option := range parts[1:] {
+			switch option {
+			case "quoted":
+				field.quoted = true
+			case "omitempty":
+				field.omitEmpty = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// UnmarshalAttributes stores the attributes into the struct pointed to by v.
+// Fields are mapped by struct tags like `m3u8:"BANDWIDTH"` and `m3u8:"CODECS,quoted"`.
+// The supported field types are string (enumerated-string, or quoted-string with the
+// quoted option), int64 and other integers (decimal-integer), float64 (signed-decimal-floating-point),
+// bool (YES or NO), time.Time (quoted ISO 8601 date), []byte (hexadecimal-sequence) and Resolution.
+// Fields whose attribute does not exist are left unchanged, and attributes without
+// a corresponding field are ignored.
+func UnmarshalAttributes(attrs Attributes, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T is not a pointer to a struct", ErrUnsupportedAttributeField, v)
+	}
+	rv = rv.Elem()
+	for _, field := range attributeFields(rv.Type()) {
+		raw, ok := attrs[field.name]
+		if !ok {
+			continue
+		}
+		if err := unmarshalAttributeValue(AttributeValue(raw), field, rv.Field(field.index)); err != nil {
+			return fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+func unmarshalAttributeValue(value AttributeValue, field attributeField, fv reflect.Value) error {
+	switch fv.Type() {
+	case timeType:
+		s, err := value.QuotedString()
+		if err != nil {
+			return err
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case resolutionType:
+		r, err := value.DecimalResolution()
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(r))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		var s string
+		var err error
+		if field.quoted {
+			s, err = value.QuotedString()
+		} else {
+			s, err = value.EnumeratedString()
+		}
+		if err != nil {
+			return err
+		}
+		fv.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := value.DecimalInteger()
+		if err != nil {
+			return err
+		}
+		if n > uint64(1)<<(fv.Type().Bits()-1)-1 {
+			return value.error(AttributeTypeDecimalInteger)
+		}
+		fv.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := value.DecimalInteger()
+		if err != nil {
+			return err
+		}
+		if fv.OverflowUint(n) {
+			return value.error(AttributeTypeDecimalInteger)
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := value.SignedDecimalFloatingPoint()
+		if err != nil {
+			return err
+		}
+		if fv.OverflowFloat(f) {
+			return value.error(AttributeTypeSignedDecimalFloatingPoint)
+		}
+		fv.SetFloat(f)
+	case reflect.Bool:
+		switch value {
+		case "YES":
+			fv.SetBool(true)
+		case "NO":
+			fv.SetBool(false)
+		default:
+			return value.error(AttributeTypeEnumeratedString)
+		}
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("%w: %s", ErrUnsupportedAttributeField, fv.Type())
+		}
+		data, err := value.HexadecimalSequence()
+		if err != nil {
+			return err
+		}
+		fv.SetBytes(data)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedAttributeField, fv.Type())
+	}
+	return nil
+}
+
+// MarshalAttributes encodes the struct v, or a pointer to it, into attributes.
+// It is the inverse of UnmarshalAttributes. Fields with the omitempty option are
+// omitted if they have the zero value or are empty slices.
+// An empty []byte field or an empty enumerated string field without the option is an error,
+// because neither a hexadecimal-sequence nor an enumerated-string can be empty.
+// A NaN or infinite float field is also an error.
+func MarshalAttributes(v any) (Attributes, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T is not a struct", ErrUnsupportedAttributeField, v)
+	}
+	attrs := make(Attributes)
+	for _, field := range attributeFields(rv.Type()) {
+		fv := rv.Field(field.index)
+		if field.omitEmpty && (fv.IsZero() || fv.Kind() == reflect.Slice && fv.Len() == 0) {
+			continue
+		}
+		value, err := marshalAttributeValue(field, fv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+		attrs[field.name] = string(value)
+	}
+	return attrs, nil
+}
+
+func marshalAttributeValue(field attributeField, fv reflect.Value) (AttributeValue, error) {
+	switch fv.Type() {
+	case timeType:
+		return EncodeQuotedString(fv.Interface().(time.Time).Format(time.RFC3339Nano)), nil
+	case resolutionType:
+		return AttributeValue(fv.Interface().(Resolution).String()), nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		if field.quoted {
+			if strings.ContainsAny(fv.String(), "\"\r\n") {
+				return "", fmt.Errorf("%w: invalid quoted-string %q", ErrInvalidAttributeValue, fv.String())
+			}
+			return EncodeQuotedString(fv.String()), nil
+		}
+		value := EncodeEnumeratedString(fv.String())
+		if _, err := value.EnumeratedString(); err != nil {
+			return "", fmt.Errorf("%w: invalid enumerated-string %q", ErrInvalidAttributeValue, fv.String())
+		}
+		return value, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fv.Int() < 0 {
+			return "", fmt.Errorf("%w: negative decimal-integer %d", ErrInvalidAttributeValue, fv.Int())
+		}
+		return EncodeDecimalInteger(uint64(fv.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return EncodeDecimalInteger(fv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(fv.Float()) || math.IsInf(fv.Float(), 0) {
+			return "", fmt.Errorf("%w: non-finite signed-decimal-floating-point %v", ErrInvalidAttributeValue, fv.Float())
+		}
+		return EncodeSignedDecimalFloatingPoint(fv.Float()), nil
+	case reflect.Bool:
+		if fv.Bool() {
+			return "YES", nil
+		}
+		return "NO", nil
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() == reflect.Uint8 {
+			if fv.Len() == 0 {
+				return "", fmt.Errorf("%w: empty hexadecimal-sequence", ErrInvalidAttributeValue)
+			}
+			return EncodeHexadecimalSequence(fv.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedAttributeField, fv.Type())
+}
@@ -0,0 +1,176 @@
+package m3u8
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testVendorAttrs struct {
+	ID         string     `m3u8:"ID,quoted"`
+	Bandwidth  int64      `m3u8:"BANDWIDTH"`
+	FrameRate  float64    `m3u8:"FRAME-RATE"`
+	Default    bool       `m3u8:"DEFAULT"`
+	StartDate  time.Time  `m3u8:"START-DATE"`
+	Cue        []byte     `m3u8:"X-CUE,omitempty"`
+	Resolution Resolution `m3u8:"RESOLUTION"`
+	Method     string     `m3u8:"METHOD,omitempty"`
+	Ignored    string     `m3u8:"-"`
+	Untagged   string
+}
+
+func TestUnmarshalAttributes(t *testing.T) {
+	attrs, err := ParseTagAttributes(`ID="ad-1",BANDWIDTH=1280000,FRAME-RATE=29.97,DEFAULT=YES,` +
+		`START-DATE="2020-01-02T03:04:05.678Z",X-CUE=0xFC30,RESOLUTION=1920x1080,METHOD=AES-128,X-UNKNOWN=1`)
+	require.NoError(t, err)
+	var v testVendorAttrs
+	require.NoError(t, UnmarshalAttributes(attrs, &v))
+	assert.Equal(t, testVendorAttrs{
+		ID:         "ad-1",
+		Bandwidth:  1280000,
+		FrameRate:  29.97,
+		Default:    true,
+		StartDate:  time.Date(2020, 1, 2, 3, 4, 5, 678000000, time.UTC),
+		Cue:        []byte{0xFC, 0x30},
+		Resolution: Resolution{Width: 1920, Height: 1080},
+		Method:     "AES-128",
+	}, v)
+
+	t.Run("missing attributes", func(t *testing.T) {
+		v := testVendorAttrs{Bandwidth: 1}
+		require.NoError(t, UnmarshalAttributes(Attributes{"DEFAULT": "NO"}, &v))
+		assert.Equal(t, testVendorAttrs{Bandwidth: 1}, v)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		testCases := []Attributes{
+			{"ID": "ad-1"},
+			{"BANDWIDTH": "-1"},
+			{"FRAME-RATE": "fast"},
+			{"DEFAULT": "TRUE"},
+			{"START-DATE": "2020-01-02T03:04:05Z"},
+			{"X-CUE": "FC30"},
+			{"RESOLUTION": "1920"},
+		}
+		for _, attrs := range testCases {
+			var v testVendorAttrs
+			err := UnmarshalAttributes(attrs, &v)
+			assert.ErrorIs(t, err, ErrInvalidAttributeValue, attrs)
+		}
+		var v testVendorAttrs
+		assert.Error(t, UnmarshalAttributes(Attributes{"START-DATE": `"2020-01-02"`}, &v))
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		var v struct {
+			N int8 `m3u8:"N"`
+		}
+		assert.ErrorIs(t, UnmarshalAttributes(Attributes{"N": "128"}, &v), ErrInvalidAttributeValue)
+		require.NoError(t, UnmarshalAttributes(Attributes{"N": "127"}, &v))
+		assert.Equal(t, int8(127), v.N)
+
+		var f struct {
+			X float32 `m3u8:"X"`
+		}
+		assert.ErrorIs(t, UnmarshalAttributes(Attributes{"X": "1" + strings.Repeat("0", 39)}, &f), ErrInvalidAttributeValue)
+		assert.ErrorIs(t, UnmarshalAttributes(Attributes{"X": "-1" + strings.Repeat("0", 39)}, &f), ErrInvalidAttributeValue)
+		require.NoError(t, UnmarshalAttributes(Attributes{"X": "1.5"}, &f))
+		assert.Equal(t, float32(1.5), f.X)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		var v struct {
+			Values []string `m3u8:"VALUES"`
+		}
+		assert.ErrorIs(t, UnmarshalAttributes(Attributes{"VALUES": "A"}, &v), ErrUnsupportedAttributeField)
+		assert.ErrorIs(t, UnmarshalAttributes(Attributes{}, v), ErrUnsupportedAttributeField)
+		assert.ErrorIs(t, UnmarshalAttributes(Attributes{}, (*testVendorAttrs)(nil)), ErrUnsupportedAttributeField)
+	})
+}
+
+func TestMarshalAttributes(t *testing.T) {
+	v := testVendorAttrs{
+		ID:         "ad-1",
+		Bandwidth:  1280000,
+		FrameRate:  29.97,
+		StartDate:  time.Date(2020, 1, 2, 3, 4, 5, 678000000, time.UTC),
+		Resolution: Resolution{Width: 1920, Height: 1080},
+		Ignored:    "ignored",
+		Untagged:   "untagged",
+	}
+	attrs, err := MarshalAttributes(v)
+	require.NoError(t, err)
+	assert.Equal(t, Attributes{
+		"ID":         `"ad-1"`,
+		"BANDWIDTH":  "1280000",
+		"FRAME-RATE": "29.97",
+		"DEFAULT":    "NO",
+		"START-DATE": `"2020-01-02T03:04:05.678Z"`,
+		"RESOLUTION": "1920x1080",
+	}, attrs)
+
+	v.Default = true
+	v.Cue = []byte{0xFC, 0x30}
+	v.Method = "AES-128"
+	attrs, err = MarshalAttributes(&v)
+	require.NoError(t, err)
+	assert.Equal(t, "YES", attrs["DEFAULT"])
+	assert.Equal(t, "0xFC30", attrs["X-CUE"])
+	assert.Equal(t, "AES-128", attrs["METHOD"])
+
+	var decoded testVendorAttrs
+	require.NoError(t, UnmarshalAttributes(attrs, &decoded))
+	v.Ignored = ""
+	v.Untagged = ""
+	assert.Equal(t, v, decoded)
+
+	t.Run("negative integer", func(t *testing.T) {
+		_, err := MarshalAttributes(testVendorAttrs{Bandwidth: -1})
+		assert.ErrorIs(t, err, ErrInvalidAttributeValue)
+	})
+
+	t.Run("empty hexadecimal-sequence", func(t *testing.T) {
+		attrs, err := MarshalAttributes(testVendorAttrs{Cue: []byte{}})
+		require.NoError(t, err)
+		assert.NotContains(t, attrs, "X-CUE")
+
+		_, err = MarshalAttributes(struct {
+			Cue []byte `m3u8:"X-CUE"`
+		}{Cue: []byte{}})
+		assert.ErrorIs(t, err, ErrInvalidAttributeValue)
+	})
+
+	t.Run("invalid quoted-string", func(t *testing.T) {
+		_, err := MarshalAttributes(testVendorAttrs{ID: "ad\"1"})
+		assert.ErrorIs(t, err, ErrInvalidAttributeValue)
+	})
+
+	t.Run("invalid enumerated-string", func(t *testing.T) {
+		for _, method := range []string{"", "AES 128", "AES,128"} {
+			_, err := MarshalAttributes(struct {
+				Method string `m3u8:"METHOD"`
+			}{Method: method})
+			assert.ErrorIs(t, err, ErrInvalidAttributeValue, method)
+		}
+	})
+
+	t.Run("non-finite float", func(t *testing.T) {
+		for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+			_, err := MarshalAttributes(testVendorAttrs{FrameRate: f})
+			assert.ErrorIs(t, err, ErrInvalidAttributeValue, f)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := MarshalAttributes(struct {
+			Values []string `m3u8:"VALUES"`
+		}{})
+		assert.ErrorIs(t, err, ErrUnsupportedAttributeField)
+		_, err = MarshalAttributes("VALUES")
+		assert.ErrorIs(t, err, ErrUnsupportedAttributeField)
+	})
+}
@@ -0,0 +1,295 @@
+package m3u8
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidAttributeValue matches any *AttributeValueError with errors.Is.
+var ErrInvalidAttributeValue = errors.New("invalid attribute value")
+
+// AttributeValueType represents a type of attribute values defined in RFC 8216 section 4.2.
+type AttributeValueType int
+
+const (
+	AttributeTypeDecimalInteger AttributeValueType = iota + 1
+	AttributeTypeHexadecimalSequence
+	AttributeTypeDecimalFloatingPoint
+	AttributeTypeSignedDecimalFloatingPoint
+	AttributeTypeQuotedString
+	AttributeTypeEnumeratedString
+	AttributeTypeDecimalResolution
+)
+
+// String returns the name of the type used in RFC 8216.
+func (t AttributeValueType) String() string {
+	switch t {
+	case AttributeTypeDecimalInteger:
+		return "decimal-integer"
+	case AttributeTypeHexadecimalSequence:
+		return "hexadecimal-sequence"
+	case AttributeTypeDecimalFloatingPoint:
+		return "decimal-floating-point"
+	case AttributeTypeSignedDecimalFloatingPoint:
+		return "signed-decimal-floating-point"
+	case AttributeTypeQuotedString:
+		return "quoted-string"
+	case AttributeTypeEnumeratedString:
+		return "enumerated-string"
+	case AttributeTypeDecimalResolution:
+		return "decimal-resolution"
+	}
+	return "unknown"
+}
+
+// AttributeValueError is returned when an attribute value does not have the expected type.
+type AttributeValueError struct {
+	// Type is the expected type.
+	Type AttributeValueType
+
+	// Value is the raw value.
+	Value string
+}
+
+// Error returns the error message.
+func (e *AttributeValueError) Error() string {
+	return fmt.Sprintf("invalid %s: %q", e.Type, e.Value)
+}
+
+// Is reports whether the target is ErrInvalidAttributeValue.
+func (e *AttributeValueError) Is(target error) bool {
+	return target == ErrInvalidAttributeValue
+}
+
+// AttributeValue represents a raw attribute value as it appears in an attribute list.
+// A quoted-string value includes the quotes.
+type AttributeValue string
+
+func (v AttributeValue) error(typ AttributeValueType) error {
+	return &AttributeValueError{Type: typ, Value: string(v)}
+}
+
+// Validate reports whether the value has the type.
+func (v AttributeValue) Validate(typ AttributeValueType) error {
+	var err error
+	switch typ {
+	case AttributeTypeDecimalInteger:
+		_, err = v.DecimalInteger()
+	case AttributeTypeHexadecimalSequence:
+		_, err = v.HexadecimalSequence()
+	case AttributeTypeDecimalFloatingPoint:
+		_, err = v.DecimalFloatingPoint()
+	case AttributeTypeSignedDecimalFloatingPoint:
+		_, err = v.SignedDecimalFloatingPoint()
+	case AttributeTypeQuotedString:
+		_, err = v.QuotedString()
+	case AttributeTypeEnumeratedString:
+		_, err = v.EnumeratedString()
+	case AttributeTypeDecimalResolution:
+		_, err = v.DecimalResolution()
+	}
+	return err
+}
+
+// DecimalInteger decodes the value as a decimal-integer.
+func (v AttributeValue) DecimalInteger() (uint64, error) {
+	if len(v) == 0 || len(v) > 20 || !isDigits(string(v)) {
+		return 0, v.error(AttributeTypeDecimalInteger)
+	}
+	n, err := strconv.ParseUint(string(v), 10, 64)
+	if err != nil {
+		return 0, v.error(AttributeTypeDecimalInteger)
+	}
+	return n, nil
+}
+
+// HexadecimalSequence decodes the value as a hexadecimal-sequence.
+func (v AttributeValue) HexadecimalSequence() ([]byte, error) {
+	s := string(v)
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") || len(s) == 2 {
+		return nil, v.error(AttributeTypeHexadecimalSequence)
+	}
+	s = s[2:]
+	if len(s)%2 != 0 {
+		s = "0" + s
+	}
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, v.error(AttributeTypeHexadecimalSequence)
+	}
+	return data, nil
+}
+
+// DecimalFloatingPoint decodes the value as a decimal-floating-point.
+func (v AttributeValue) DecimalFloatingPoint() (float64, error) {
+	f, ok := parseDecimalFloatingPoint(string(v))
+	if !ok {
+		return 0, v.error(AttributeTypeDecimalFloatingPoint)
+	}
+	return f, nil
+}
+
+// SignedDecimalFloatingPoint decodes the value as a signed-decimal-floating-point.
+func (v AttributeValue) SignedDecimalFloatingPoint() (float64, error) {
+	f, ok := parseDecimalFloatingPoint(strings.TrimPrefix(string(v), "-"))
+	if !ok {
+		return 0, v.error(AttributeTypeSignedDecimalFloatingPoint)
+	}
+	if strings.HasPrefix(string(v), "-") {
+		return -f, nil
+	}
+	return f, nil
+}
+
+// QuotedString decodes the value as a quoted-string and returns it without the quotes.
+func (v AttributeValue) QuotedString() (string, error) {
+	s := string(v)
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", v.error(AttributeTypeQuotedString)
+	}
+	s = s[1 : len(s)-1]
+	if strings.ContainsAny(s, "\"\r\n") {
+		return "", v.error(AttributeTypeQuotedString)
+	}
+	return s, nil
+}
+
+// EnumeratedString decodes the value as an enumerated-string.
+func (v AttributeValue) EnumeratedString() (string, error) {
+	if len(v) == 0 || strings.ContainsAny(string(v), "\", \t\r\n") {
+		return "", v.error(AttributeTypeEnumeratedString)
+	}
+	return string(v), nil
+}
+
+// DecimalResolution decodes the value as a decimal-resolution.
+func (v AttributeValue) DecimalResolution() (Resolution, error) {
+	idx := strings.Index(string(v), "x")
+	if idx == -1 {
+		return Resolution{}, v.error(AttributeTypeDecimalResolution)
+	}
+	width, err := v[:idx].DecimalInteger()
+	if err != nil || width > maxInt {
+		return Resolution{}, v.error(AttributeTypeDecimalResolution)
+	}
+	height, err := v[idx+1:].DecimalInteger()
+	if err != nil || height > maxInt {
+		return Resolution{}, v.error(AttributeTypeDecimalResolution)
+	}
+	return Resolution{Width: int(width), Height: int(height)}, nil
+}
+
+// decimalInteger64 decodes a decimal-integer which fits in int64.
+func decimalInteger64(value string) (int64, error) {
+	n, err := AttributeValue(value).DecimalInteger()
+	if err != nil {
+		return 0, err
+	}
+	if n > math.MaxInt64 {
+		return 0, &AttributeValueError{Type: AttributeTypeDecimalInteger, Value: value}
+	}
+	return int64(n), nil
+}
+
+// unquote returns the value without the surrounding quotes.
+// Unlike QuotedString, it accepts any value.
+func (v AttributeValue) unquote() string {
+	return strings.Trim(string(v), `"`)
+}
+
+const maxInt = uint64(^uint(0) >> 1)
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func parseDecimalFloatingPoint(s string) (float64, bool) {
+	idx := strings.Index(s, ".")
+	intPart, fracPart := s, ""
+	if idx != -1 {
+		intPart, fracPart = s[:idx], s[idx+1:]
+	}
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// EncodeDecimalInteger encodes a decimal-integer.
+func EncodeDecimalInteger(n uint64) AttributeValue {
+	return AttributeValue(strconv.FormatUint(n, 10))
+}
+
+// EncodeHexadecimalSequence encodes a hexadecimal-sequence.
+// An empty data results in "0x", which Validate reports as invalid.
+func EncodeHexadecimalSequence(data []byte) AttributeValue {
+	return AttributeValue("0x" + strings.ToUpper(hex.EncodeToString(data)))
+}
+
+// EncodeDecimalFloatingPoint encodes a decimal-floating-point.
+// It does not check f, so a negative, NaN or infinite value results in a value which
+// Validate reports as invalid. Use EncodeSignedDecimalFloatingPoint for a negative value.
+func EncodeDecimalFloatingPoint(f float64) AttributeValue {
+	return AttributeValue(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// EncodeSignedDecimalFloatingPoint encodes a signed-decimal-floating-point.
+// It does not check f, so a NaN or infinite value results in a value which
+// Validate reports as invalid.
+func EncodeSignedDecimalFloatingPoint(f float64) AttributeValue {
+	return EncodeDecimalFloatingPoint(f)
+}
+
+// EncodeQuotedString encodes a quoted-string.
+// It does not check s, so a double quote, CR or LF in s results in a value which
+// Validate reports as invalid.
+func EncodeQuotedString(s string) AttributeValue {
+	return AttributeValue(`"` + s + `"`)
+}
+
+// EncodeEnumeratedString encodes an enumerated-string.
+func EncodeEnumeratedString(s string) AttributeValue {
+	return AttributeValue(s)
+}
+
+// Resolution represents a decimal-resolution.
+type Resolution struct {
+	Width  int
+	Height int
+}
+
+// String encodes the resolution as a decimal-resolution.
+func (r Resolution) String() string {
+	return strconv.Itoa(r.Width) + "x" + strconv.Itoa(r.Height)
+}
+
+// validateAttributes checks the types of the attributes which appear in types.
+func validateAttributes(attrs Attributes, types map[string]AttributeValueType) error {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		if _, ok := types[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := AttributeValue(attrs[key]).Validate(types[key]); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
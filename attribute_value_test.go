@@ -0,0 +1,115 @@
+package m3u8
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttributeValue(t *testing.T) {
+	testCases := []struct {
+		typ     AttributeValueType
+		valid   []string
+		invalid []string
+	}{
+		{
+			typ:     AttributeTypeDecimalInteger,
+			valid:   []string{"0", "1280000", "18446744073709551615"},
+			invalid: []string{"", "-1", "1.5", `"1280000"`, "0x10", "18446744073709551616"},
+		},
+		{
+			typ:     AttributeTypeHexadecimalSequence,
+			valid:   []string{"0x0", "0xFC30", "0Xfc30"},
+			invalid: []string{"", "0x", "FC30", "0xZZ", `"0xFC30"`},
+		},
+		{
+			typ:     AttributeTypeDecimalFloatingPoint,
+			valid:   []string{"0", "29.97", "10.", ".5"},
+			invalid: []string{"", ".", "-1.5", "1e3", "1.2.3", `"29.97"`},
+		},
+		{
+			typ:     AttributeTypeSignedDecimalFloatingPoint,
+			valid:   []string{"0", "-1.5", "29.97"},
+			invalid: []string{"", "-", "+1.5", "--1"},
+		},
+		{
+			typ:     AttributeTypeQuotedString,
+			valid:   []string{`""`, `"avc1.4d401f,mp4a.40.2"`},
+			invalid: []string{"", `"`, "avc1", `"a"b"`},
+		},
+		{
+			typ:     AttributeTypeEnumeratedString,
+			valid:   []string{"YES", "AES-128", "CLOSED-CAPTIONS"},
+			invalid: []string{"", `"YES"`, "Y S", "A,B"},
+		},
+		{
+			typ:     AttributeTypeDecimalResolution,
+			valid:   []string{"1920x1080", "0x0"},
+			invalid: []string{"", "1920", "1920x", "x1080", "1920X1080", `"1920x1080"`, "-1x10"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.typ.String(), func(t *testing.T) {
+			for _, value := range tc.valid {
+				assert.NoError(t, AttributeValue(value).Validate(tc.typ), value)
+			}
+			for _, value := range tc.invalid {
+				err := AttributeValue(value).Validate(tc.typ)
+				require.Error(t, err, value)
+				assert.ErrorIs(t, err, ErrInvalidAttributeValue)
+				var valueErr *AttributeValueError
+				require.ErrorAs(t, err, &valueErr)
+				assert.Equal(t, tc.typ, valueErr.Type)
+				assert.Equal(t, value, valueErr.Value)
+			}
+		})
+	}
+}
+
+func TestAttributeValueDecode(t *testing.T) {
+	n, err := AttributeValue("1280000").DecimalInteger()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1280000), n)
+
+	data, err := AttributeValue("0xFC30").HexadecimalSequence()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xfc, 0x30}, data)
+	data, err = AttributeValue("0x130").HexadecimalSequence()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x30}, data)
+
+	f, err := AttributeValue("29.97").DecimalFloatingPoint()
+	require.NoError(t, err)
+	assert.Equal(t, 29.97, f)
+	f, err = AttributeValue("-1.5").SignedDecimalFloatingPoint()
+	require.NoError(t, err)
+	assert.Equal(t, -1.5, f)
+
+	s, err := AttributeValue(`"avc1.4d401f"`).QuotedString()
+	require.NoError(t, err)
+	assert.Equal(t, "avc1.4d401f", s)
+	s, err = AttributeValue("AES-128").EnumeratedString()
+	require.NoError(t, err)
+	assert.Equal(t, "AES-128", s)
+
+	r, err := AttributeValue("1920x1080").DecimalResolution()
+	require.NoError(t, err)
+	assert.Equal(t, Resolution{Width: 1920, Height: 1080}, r)
+
+	assert.Equal(t, `invalid decimal-integer: "\"1280000\""`, AttributeValue(`"1280000"`).Validate(AttributeTypeDecimalInteger).Error())
+}
+
+func TestAttributeValueEncode(t *testing.T) {
+	assert.Equal(t, AttributeValue("1280000"), EncodeDecimalInteger(1280000))
+	assert.Equal(t, AttributeValue("0xFC30"), EncodeHexadecimalSequence([]byte{0xfc, 0x30}))
+	assert.Equal(t, AttributeValue("29.97"), EncodeDecimalFloatingPoint(29.97))
+	assert.Equal(t, AttributeValue("-1.5"), EncodeSignedDecimalFloatingPoint(-1.5))
+	assert.Equal(t, AttributeValue(`"avc1.4d401f"`), EncodeQuotedString("avc1.4d401f"))
+	assert.Equal(t, AttributeValue("AES-128"), EncodeEnumeratedString("AES-128"))
+	assert.Equal(t, "1920x1080", Resolution{Width: 1920, Height: 1080}.String())
+
+	assert.Error(t, EncodeDecimalFloatingPoint(-1.5).Validate(AttributeTypeDecimalFloatingPoint))
+	assert.Error(t, EncodeQuotedString(`say "hi"`).Validate(AttributeTypeQuotedString))
+	assert.Error(t, EncodeQuotedString("line\r\nbreak").Validate(AttributeTypeQuotedString))
+}
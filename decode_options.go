@@ -0,0 +1,272 @@
+package m3u8
+
+import (
+	"bufio"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMissingHeader is returned when the playlist does not start with an EXTM3U tag.
+	ErrMissingHeader = errors.New("missing EXTM3U tag")
+
+	// ErrMissingExtInf is returned when a segment URI is not preceded by an EXTINF tag.
+	ErrMissingExtInf = errors.New("missing EXTINF tag")
+
+	// ErrMissingStreamInf is returned when a URI in a master playlist is not preceded by an EXT-X-STREAM-INF tag.
+	ErrMissingStreamInf = errors.New("missing EXT-X-STREAM-INF tag")
+
+	// ErrInvalidTagValue is returned when the value of a tag is invalid.
+	ErrInvalidTagValue = errors.New("invalid tag value")
+
+	// ErrDuplicateTag is returned when a tag which is not repeatable appears more than once.
+	ErrDuplicateTag = errors.New("duplicate tag")
+)
+
+// DecodeMode represents how decoders handle problems in a playlist.
+type DecodeMode int
+
+const (
+	// DecodeModeDefault fails only on problems which prevent the decoder
+	// from building the playlist, and ignores the others.
+	DecodeModeDefault DecodeMode = iota
+
+	// DecodeModeStrict enforces the syntax of RFC 8216 and fails on any problem.
+	DecodeModeStrict
+
+	// DecodeModeLenient never fails on problems in the playlist.
+	// It records them to the Warnings field of the playlist and skips the invalid lines
+	// which cannot be retained.
+	DecodeModeLenient
+)
+
+// DecodeOptions represents the options of decoders.
+// A nil *DecodeOptions is equivalent to the zero value.
+type DecodeOptions struct {
+	// PreserveTagOrder makes the decoder record the original order of tags
+	// to the TagOrder fields, so that Encode writes the tags in the same order.
+	// A TagOrder field can be edited or set by hand. See Tags#ListInOrder.
+	// Without it, Encode sorts the tags, but still writes the comments and
+	// EXT-X-PART tags of a decoded media playlist where they were among the other tags.
+	// Comments are decoded to the Comments fields regardless of it, each without the leading "#",
+	// and CommentMarker in the TagOrder fields marks their positions.
+	// Blank lines are not recorded and CRLF line terminators are not kept,
+	// so Encode omits the blank lines and always terminates lines with LF.
+	PreserveTagOrder bool
+
+	// PreserveAttributeOrder makes the decoder record the original order of
+	// attribute keys to the AttributeOrder fields of Stream, Alternative, Part,
+	// PreloadHint and RenditionReport, so that Encode writes the attributes in the same order.
+	// Without it, the AttributeOrder fields are nil and Encode sorts the attributes.
+	// The keys which are not in AttributeOrder follow the others. See Attributes#StringInOrder.
+	PreserveAttributeOrder bool
+
+	// Mode specifies how the decoder handles problems in the playlist.
+	Mode DecodeMode
+
+	// MaxLineLength is the maximum length of a line in bytes, excluding the line terminator.
+	// If it is 0, DefaultMaxLineLength is used.
+	MaxLineLength int
+
+	// MaxInputSize is the maximum size of the whole playlist in bytes.
+	// If it is 0, the size is not limited.
+	MaxInputSize int64
+
+	// MaxSegments is the maximum number of media segments in a media playlist.
+	// If it is 0, the number is not limited.
+	MaxSegments int
+
+	// MaxTagsPerSegment is the maximum number of tags applied to a media segment,
+	// including EXT-X-PART tags and the comments among them.
+	// The tags and comments after the last segment are counted as those of another segment.
+	// If it is 0, the number is not limited.
+	MaxTagsPerSegment int
+
+	// MaxPlaylistTags is the maximum number of tags and comments which are not applied
+	// to a media segment, such as those before the first segment of a media playlist,
+	// EXT-X-PRELOAD-HINT and EXT-X-RENDITION-REPORT tags, and all the tags and comments
+	// of a master playlist.
+	// If it is 0, the number is not limited.
+	MaxPlaylistTags int
+
+	// TagRegistry classifies the tags in the playlist.
+	// If it is nil, DefaultTagRegistry is used.
+	// The decoded playlist keeps it, and Encode sorts the tags by the orders in it.
+	TagRegistry *TagRegistry
+}
+
+// DefaultMaxLineLength is the default value of DecodeOptions.MaxLineLength.
+const DefaultMaxLineLength = bufio.MaxScanTokenSize
+
+func (opts *DecodeOptions) parseTagAttributes(attributes string) (Attributes, []string, error) {
+	return parseTagAttributes(attributes, opts.PreserveAttributeOrder)
+}
+
+func (opts *DecodeOptions) tagRegistry() *TagRegistry {
+	return tagRegistryOrDefault(opts.TagRegistry)
+}
+
+func (opts *DecodeOptions) maxLineLength() int {
+	if opts.MaxLineLength > 0 {
+		return opts.MaxLineLength
+	}
+	return DefaultMaxLineLength
+}
+
+// decodeState holds the state shared by decoders.
+type decodeState struct {
+	opts     *DecodeOptions
+	warnings []error
+}
+
+func newDecodeState(opts *DecodeOptions) *decodeState {
+	if opts == nil {
+		opts = &DecodeOptions{}
+	}
+	return &decodeState{opts: opts}
+}
+
+// validates reports whether the decoder validates the syntax.
+func (d *decodeState) validates() bool {
+	return d.opts.Mode != DecodeModeDefault
+}
+
+// report handles a problem in the playlist.
+// It returns the error if the decoder must stop, otherwise it returns nil.
+// fatal reports whether the problem is an error in DecodeModeDefault.
+func (d *decodeState) report(err *DecodeError, fatal bool) error {
+	switch d.opts.Mode {
+	case DecodeModeStrict:
+		return err
+	case DecodeModeLenient:
+		d.warnings = append(d.warnings, err)
+		return nil
+	}
+	if fatal {
+		return err
+	}
+	return nil
+}
+
+// checkCount returns a *LimitError if n exceeds the limit.
+// A limit of 0 means no limit.
+func (d *decodeState) checkCount(lineNumber int, name string, n int, limit int) error {
+	if limit > 0 && n > limit {
+		return &LimitError{Limit: name, Max: int64(limit), Line: lineNumber}
+	}
+	return nil
+}
+
+// checkHeader validates the first line of the playlist.
+func (d *decodeState) checkHeader(token Token) error {
+	if token.Line != 1 || !d.validates() || token.Type == TokenHeader {
+		return nil
+	}
+	return d.report(newDecodeError(token.Line, token.Raw, token.Name, ErrMissingHeader), false)
+}
+
+// checkAttributes validates the types of the attributes of a tag.
+func (d *decodeState) checkAttributes(lineNumber int, line string, name string, validate func() error) error {
+	if !d.validates() {
+		return nil
+	}
+	if err := validate(); err != nil {
+		return d.report(newDecodeError(lineNumber, line, name, err), false)
+	}
+	return nil
+}
+
+// checkTag validates the value of a tag which the decoder retains as a string.
+func (d *decodeState) checkTag(lineNumber int, line string, name string) error {
+	if !d.validates() {
+		return nil
+	}
+	if err := validateTagValue(name, AttributeString(line)); err != nil {
+		return d.report(newDecodeError(lineNumber, line, name, err), false)
+	}
+	return nil
+}
+
+// checkDuplicate validates that the tag is repeatable if tags already contain it.
+func (d *decodeState) checkDuplicate(lineNumber int, line string, name string, tags Tags) error {
+	if !d.validates() {
+		return nil
+	}
+	if _, exists := tags[name]; !exists || d.opts.tagRegistry().Definition(name).Repeatable {
+		return nil
+	}
+	return d.report(newDecodeError(lineNumber, line, name, ErrDuplicateTag), false)
+}
+
+var attributeListTagSet = map[string]struct{}{
+	TagExtXMedia:           {},
+	TagExtXStreamInf:       {},
+	TagExtXIFrameStreamInf: {},
+	TagExtXSessionData:     {},
+	TagExtXSessionKey:      {},
+	TagExtXStart:           {},
+	TagExtXServerControl:   {},
+	TagExtXPartInf:         {},
+	TagExtXSkip:            {},
+	TagExtXPreloadHint:     {},
+	TagExtXRenditionReport: {},
+	TagExtXKey:             {},
+	TagExtXMap:             {},
+	TagExtXDateRange:       {},
+	TagExtXPart:            {},
+}
+
+var decimalIntegerTagSet = map[string]struct{}{
+	TagExtXVersion:               {},
+	TagExtXTargetDuration:        {},
+	TagExtXMediaSequence:         {},
+	TagExtXDiscontinuitySequence: {},
+	TagExtXBitrate:               {},
+}
+
+func validateTagValue(name string, value string) error {
+	if _, ok := attributeListTagSet[name]; ok {
+		attrs, err := ParseTagAttributes(value)
+		if err != nil {
+			return err
+		}
+		switch name {
+		case TagExtXDateRange:
+			return DateRangeAttrs(attrs).Validate()
+		case TagExtXKey, TagExtXSessionKey:
+			return KeyAttrs(attrs).Validate()
+		}
+		return nil
+	}
+	if _, ok := decimalIntegerTagSet[name]; ok {
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return ErrInvalidTagValue
+		}
+		return nil
+	}
+	switch name {
+	case TagExtInf:
+		idx := strings.Index(value, ",")
+		if idx == -1 {
+			return ErrInvalidTagValue
+		}
+		if _, err := strconv.ParseFloat(value[:idx], 64); err != nil {
+			return ErrInvalidTagValue
+		}
+	case TagExtXByteRange:
+		if _, err := ParseByteRange(value); err != nil {
+			return ErrInvalidTagValue
+		}
+	case TagExtXProgramDateTime:
+		if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+			return ErrInvalidTagValue
+		}
+	case TagExtXPlaylistType:
+		if value != string(MediaPlaylistTypeEvent) && value != string(MediaPlaylistTypeVOD) {
+			return ErrInvalidTagValue
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package m3u8
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeModes(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		master  bool
+		line    int
+		wantErr error
+		fatal   bool
+	}{
+		{
+			name:    "missing header",
+			input:   "#EXT-X-TARGETDURATION:6\n#EXTINF:6.000,\nseg0.ts\n",
+			line:    1,
+			wantErr: ErrMissingHeader,
+		},
+		{
+			name:    "missing EXTINF",
+			input:   "#EXTM3U\n#EXT-X-TARGETDURATION:6\nseg0.ts\n",
+			line:    3,
+			wantErr: ErrMissingExtInf,
+		},
+		{
+			name:    "invalid target duration",
+			input:   "#EXTM3U\n#EXT-X-TARGETDURATION:six\n#EXTINF:6.000,\nseg0.ts\n",
+			line:    2,
+			wantErr: ErrInvalidTagValue,
+		},
+		{
+			name:    "invalid EXTINF",
+			input:   "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.000\nseg0.ts\n",
+			line:    3,
+			wantErr: ErrInvalidTagValue,
+		},
+		{
+			name:    "invalid attribute list",
+			input:   "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXT-X-KEY:METHOD=\"AES-128\n#EXTINF:6.000,\nseg0.ts\n",
+			line:    3,
+			wantErr: ErrInvalidAttributes,
+		},
+		{
+			name:    "invalid part",
+			input:   "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXT-X-PART:URI=\"part0.mp4\n#EXTINF:6.000,\nseg0.ts\n",
+			line:    3,
+			wantErr: ErrInvalidAttributes,
+			fatal:   true,
+		},
+		{
+			name:    "quoted BANDWIDTH",
+			input:   "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=\"1280000\"\nlow.m3u8\n",
+			master:  true,
+			line:    2,
+			wantErr: ErrInvalidAttributeValue,
+		},
+		{
+			name:    "invalid DATERANGE",
+			input:   "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXT-X-DATERANGE:ID=\"4\",START-DATE=2023-05-12T05:09:20.988Z\n#EXTINF:6.000,\nseg0.ts\n",
+			line:    3,
+			wantErr: ErrInvalidAttributeValue,
+		},
+		{
+			name:    "missing EXT-X-STREAM-INF",
+			input:   "#EXTM3U\n#EXT-X-INDEPENDENT-SEGMENTS\n#EXT-X-STREAM-INF:BANDWIDTH=1280000\nlow.m3u8\nhigh.m3u8\n",
+			master:  true,
+			line:    5,
+			wantErr: ErrMissingStreamInf,
+		},
+		{
+			name:    "invalid media type",
+			input:   "#EXTM3U\n#EXT-X-MEDIA:TYPE=FOO,GROUP-ID=\"aac\",NAME=\"en\"\n#EXT-X-STREAM-INF:BANDWIDTH=1280000\nlow.m3u8\n",
+			master:  true,
+			line:    2,
+			wantErr: ErrInvalidMediaType,
+			fatal:   true,
+		},
+	}
+	decode := func(input string, master bool, mode DecodeMode) (Playlist, []error, error) {
+		opts := &DecodeOptions{Mode: mode}
+		if master {
+			playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			return playlist, playlist.Warnings, nil
+		}
+		playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return playlist, playlist.Warnings, nil
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("default", func(t *testing.T) {
+				_, warnings, err := decode(tc.input, tc.master, DecodeModeDefault)
+				if tc.fatal {
+					assert.ErrorIs(t, err, tc.wantErr)
+				} else {
+					require.NoError(t, err)
+					assert.Empty(t, warnings)
+				}
+			})
+
+			t.Run("strict", func(t *testing.T) {
+				_, _, err := decode(tc.input, tc.master, DecodeModeStrict)
+				require.Error(t, err)
+				assert.ErrorIs(t, err, tc.wantErr)
+				var decodeErr *DecodeError
+				require.ErrorAs(t, err, &decodeErr)
+				assert.Equal(t, tc.line, decodeErr.Line)
+			})
+
+			t.Run("lenient", func(t *testing.T) {
+				playlist, warnings, err := decode(tc.input, tc.master, DecodeModeLenient)
+				require.NoError(t, err)
+				require.NotNil(t, playlist)
+				require.Len(t, warnings, 1)
+				assert.ErrorIs(t, warnings[0], tc.wantErr)
+				var decodeErr *DecodeError
+				require.ErrorAs(t, warnings[0], &decodeErr)
+				assert.Equal(t, tc.line, decodeErr.Line)
+			})
+		})
+	}
+
+	t.Run("valid playlists", func(t *testing.T) {
+		for _, mode := range []DecodeMode{DecodeModeStrict, DecodeModeLenient} {
+			media, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(sampleLowLatencyInput)), &DecodeOptions{Mode: mode})
+			require.NoError(t, err)
+			assert.Empty(t, media.Warnings)
+			master, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(sampleMultipleGroupsInput)), &DecodeOptions{Mode: mode})
+			require.NoError(t, err)
+			assert.Empty(t, master.Warnings)
+		}
+	})
+
+	t.Run("lenient skips invalid lines", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-MEDIA:TYPE=FOO,GROUP-ID=\"aac\",NAME=\"en\"\n" +
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"ja\"\n" +
+			"#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO=\"aac\"\n" +
+			"#EXT-X-INDEPENDENT-SEGMENTS\n" +
+			"low.m3u8\n"
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{Mode: DecodeModeLenient})
+		require.NoError(t, err)
+		require.Len(t, playlist.Warnings, 3)
+		assert.ErrorIs(t, playlist.Warnings[0], ErrInvalidMediaType)
+		assert.ErrorIs(t, playlist.Warnings[1], ErrInvalidStreamInf)
+		assert.ErrorIs(t, playlist.Warnings[2], ErrMissingStreamInf)
+		assert.Len(t, playlist.Alternatives.Audio["aac"], 1)
+		require.Len(t, playlist.Streams, 1)
+		assert.Equal(t, "low.m3u8", playlist.Streams[0].URI)
+		assert.Nil(t, playlist.Streams[0].Attributes)
+		assert.Equal(t, []string{""}, playlist.Tags[TagExtXIndependentSegments])
+	})
+
+	t.Run("unexpected segment tags", func(t *testing.T) {
+		input := "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.000,\n"
+		_, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{Mode: DecodeModeStrict})
+		assert.ErrorIs(t, err, ErrUnexpectedSegmentTags)
+		playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{Mode: DecodeModeLenient})
+		require.NoError(t, err)
+		assert.Equal(t, []error{ErrUnexpectedSegmentTags}, playlist.Warnings)
+	})
+
+	t.Run("DecodePlaylistWithOptions", func(t *testing.T) {
+		input := "#EXTM3U\n#EXT-X-TARGETDURATION:6\nseg0.ts\n#EXTINF:6.000,\nseg1.ts\n"
+		_, err := DecodePlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{Mode: DecodeModeStrict})
+		assert.ErrorIs(t, err, ErrMissingExtInf)
+		playlist, err := DecodePlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{Mode: DecodeModeLenient})
+		require.NoError(t, err)
+		require.Len(t, playlist.Media().Warnings, 1)
+		assert.ErrorIs(t, playlist.Media().Warnings[0], ErrMissingExtInf)
+	})
+}
+
+func TestDecodeLimits(t *testing.T) {
+	const input = "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		"#EXT-X-DISCONTINUITY\n" +
+		"#EXTINF:6.000,\n" +
+		"seg0.ts\n" +
+		"#EXTINF:6.000,\n" +
+		"seg1.ts\n"
+
+	testCases := []struct {
+		name  string
+		opts  *DecodeOptions
+		limit string
+		line  int
+	}{
+		{
+			name:  "MaxLineLength",
+			opts:  &DecodeOptions{MaxLineLength: 20},
+			limit: "MaxLineLength",
+			line:  2,
+		},
+		{
+			name:  "MaxInputSize",
+			opts:  &DecodeOptions{MaxInputSize: int64(len(input) - 1)},
+			limit: "MaxInputSize",
+		},
+		{
+			name:  "MaxSegments",
+			opts:  &DecodeOptions{MaxSegments: 1},
+			limit: "MaxSegments",
+			line:  7,
+		},
+		{
+			name:  "MaxTagsPerSegment",
+			opts:  &DecodeOptions{MaxTagsPerSegment: 1},
+			limit: "MaxTagsPerSegment",
+			line:  4,
+		},
+		{
+			name:  "MaxPlaylistTags",
+			opts:  &DecodeOptions{MaxPlaylistTags: 1},
+			limit: "MaxPlaylistTags",
+			line:  2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), tc.opts)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrLimitExceeded)
+			var limitErr *LimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, tc.limit, limitErr.Limit)
+			assert.Equal(t, tc.line, limitErr.Line)
+
+			_, err = DecodePlaylistWithOptions(bytes.NewReader([]byte(input)), tc.opts)
+			assert.ErrorIs(t, err, ErrLimitExceeded)
+		})
+	}
+
+	t.Run("within limits", func(t *testing.T) {
+		opts := &DecodeOptions{
+			MaxLineLength:     23,
+			MaxInputSize:      int64(len(input)),
+			MaxSegments:       2,
+			MaxTagsPerSegment: 2,
+			MaxPlaylistTags:   2,
+		}
+		playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), opts)
+		require.NoError(t, err)
+		assert.Len(t, playlist.Segments, 2)
+		_, err = DecodePlaylistWithOptions(bytes.NewReader([]byte(input)), opts)
+		require.NoError(t, err)
+	})
+
+	t.Run("comments", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-TARGETDURATION:6\n" +
+			"#header\n" +
+			"#EXTINF:6.000,\n" +
+			"#segment\n" +
+			"seg0.ts\n" +
+			"#trailer\n" +
+			"#trailer\n"
+		testCases := []struct {
+			opts  *DecodeOptions
+			limit string
+			line  int
+		}{
+			{opts: &DecodeOptions{MaxPlaylistTags: 2}, limit: "MaxPlaylistTags", line: 3},
+			{opts: &DecodeOptions{MaxTagsPerSegment: 1}, limit: "MaxTagsPerSegment", line: 5},
+			{opts: &DecodeOptions{MaxTagsPerSegment: 2, MaxPlaylistTags: 3}, limit: "", line: 0},
+		}
+		for _, tc := range testCases {
+			_, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), tc.opts)
+			if tc.limit == "" {
+				require.NoError(t, err)
+				continue
+			}
+			var limitErr *LimitError
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, tc.limit, limitErr.Limit)
+			assert.Equal(t, tc.line, limitErr.Line)
+		}
+	})
+
+	t.Run("master playlist tags", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#comment\n" +
+			"#EXT-X-STREAM-INF:BANDWIDTH=1280000\n" +
+			"low.m3u8\n"
+		_, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{MaxPlaylistTags: 3})
+		require.NoError(t, err)
+		_, err = DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{MaxPlaylistTags: 2})
+		var limitErr *LimitError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, "MaxPlaylistTags", limitErr.Limit)
+		assert.Equal(t, 3, limitErr.Line)
+	})
+
+	t.Run("long line", func(t *testing.T) {
+		value := strings.Repeat("a", 100*1024)
+		input := "#EXTM3U\n#EXT-X-SESSION-DATA:DATA-ID=\"com.example\",VALUE=\"" + value + "\"\n"
+		_, err := DecodeMasterPlaylist(bytes.NewReader([]byte(input)))
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{MaxLineLength: 1024 * 1024})
+		require.NoError(t, err)
+		assert.Equal(t, []string{`DATA-ID="com.example",VALUE="` + value + `"`}, playlist.Tags[TagExtXSessionData])
+	})
+}
@@ -0,0 +1,139 @@
+// Package decrypt decrypts media segments encrypted with METHOD=AES-128.
+//
+// SAMPLE-AES and SAMPLE-AES-CTR are not supported, because they require parsing
+// the container format to decrypt the elementary streams.
+package decrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+
+	m3u8 "github.com/abema/go-simple-m3u8"
+)
+
+var (
+	// ErrUnsupportedMethod is returned when the encryption method is not AES-128
+	// or no key has KEYFORMAT="identity".
+	ErrUnsupportedMethod = errors.New("unsupported encryption method")
+
+	// ErrInvalidKey is returned when the key is not 16 bytes long.
+	ErrInvalidKey = errors.New("invalid key")
+
+	// ErrInvalidCiphertext is returned when the length of the data is not a multiple of the block size.
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+	// ErrInvalidPadding is returned when the decrypted data does not end with valid PKCS7 padding.
+	ErrInvalidPadding = errors.New("invalid padding")
+
+	// ErrMissingIV is returned when the IV attribute is required but does not exist.
+	ErrMissingIV = errors.New("missing IV attribute")
+)
+
+// Decrypter decrypts media segments with the keys loaded by a KeyLoader.
+type Decrypter struct {
+	loader KeyLoader
+}
+
+// NewDecrypter creates a Decrypter.
+// Use NewCachedKeyLoader to avoid loading the same key for each segment.
+func NewDecrypter(loader KeyLoader) *Decrypter {
+	return &Decrypter{loader: loader}
+}
+
+// DecryptSegment decrypts the data of the media segment.
+// The key is the EXT-X-KEY tag with KEYFORMAT="identity" which applies to the segment.
+// See m3u8.MediaPlaylist#Resolve.
+// If the IV attribute does not exist, the IV is derived from the media sequence number.
+// If the segment is not encrypted, it returns the data as is.
+// If the segment is encrypted only with other key formats, it returns ErrUnsupportedMethod.
+func (d *Decrypter) DecryptSegment(ctx context.Context, segment *m3u8.ResolvedSegment, data []byte) ([]byte, error) {
+	key, err := identityKey(segment.Keys)
+	if err != nil {
+		return nil, err
+	} else if key == nil {
+		return data, nil
+	}
+	iv, err := key.IVForSequence(segment.Segment.Sequence)
+	if err != nil {
+		return nil, err
+	}
+	return d.decrypt(ctx, key, iv, data)
+}
+
+// DecryptInitSection decrypts the data of the media initialization section
+// declared by the EXT-X-MAP tag which applies to the segment.
+// The key is the one which applied when the EXT-X-MAP tag appeared. See m3u8.ResolvedSegment#MapKeys.
+// The IV attribute is required by RFC 8216 in this case.
+// If the initialization section is not encrypted, it returns the data as is.
+func (d *Decrypter) DecryptInitSection(ctx context.Context, segment *m3u8.ResolvedSegment, data []byte) ([]byte, error) {
+	key, err := identityKey(segment.MapKeys)
+	if err != nil {
+		return nil, err
+	} else if key == nil {
+		return data, nil
+	}
+	iv, err := key.IV()
+	if err != nil {
+		return nil, err
+	}
+	if iv == nil {
+		return nil, ErrMissingIV
+	}
+	return d.decrypt(ctx, key, *iv, data)
+}
+
+func (d *Decrypter) decrypt(ctx context.Context, key m3u8.KeyAttrs, iv [16]byte, data []byte) ([]byte, error) {
+	if key.Method() != m3u8.KeyMethodAES128 {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMethod, key.Method())
+	}
+	secret, err := d.loader.LoadKey(ctx, key.URI())
+	if err != nil {
+		return nil, err
+	}
+	return DecryptAES128(secret, iv, data)
+}
+
+// identityKey returns the key with KEYFORMAT="identity".
+// It returns nil if the keys do not encrypt the data.
+func identityKey(keys []m3u8.KeyAttrs) (m3u8.KeyAttrs, error) {
+	encrypted := false
+	for _, key := range keys {
+		if key.Method() == m3u8.KeyMethodNone {
+			continue
+		}
+		if key.KeyFormat() == m3u8.KeyFormatIdentity {
+			return key, nil
+		}
+		encrypted = true
+	}
+	if encrypted {
+		return nil, fmt.Errorf("%w: no key with KEYFORMAT=%q", ErrUnsupportedMethod, m3u8.KeyFormatIdentity)
+	}
+	return nil, nil
+}
+
+// DecryptAES128 decrypts the data with AES-128 in CBC mode and removes the PKCS7 padding.
+func DecryptAES128(key []byte, iv [16]byte, data []byte) ([]byte, error) {
+	if len(key) != aes.BlockSize {
+		return nil, ErrInvalidKey
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv[:]).CryptBlocks(plaintext, data)
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize ||
+		!bytes.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrInvalidPadding
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
@@ -0,0 +1,177 @@
+package decrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	m3u8 "github.com/abema/go-simple-m3u8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encryptAES128(t *testing.T, key []byte, iv [16]byte, data []byte) []byte {
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	plaintext := append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv[:]).CryptBlocks(ciphertext, plaintext)
+	return ciphertext
+}
+
+func TestDecryptSegment(t *testing.T) {
+	key1 := bytes.Repeat([]byte{0x01}, 16)
+	key2 := bytes.Repeat([]byte{0x02}, 16)
+	input := "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		"#EXT-X-MEDIA-SEQUENCE:7\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key1\"\n" +
+		"#EXT-X-MAP:URI=\"init.mp4\"\n" +
+		"#EXTINF:6.000,\n" +
+		"seg7.ts\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key2\",IV=0x000102030405060708090A0B0C0D0E0F\n" +
+		"#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"skd://key2\",KEYFORMAT=\"com.apple.streamingkeydelivery\"\n" +
+		"#EXTINF:6.000,\n" +
+		"seg8.ts\n" +
+		"#EXT-X-KEY:METHOD=NONE\n" +
+		"#EXTINF:6.000,\n" +
+		"seg9.ts\n" +
+		"#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"key3\"\n" +
+		"#EXTINF:6.000,\n" +
+		"seg10.ts\n"
+	playlist, err := m3u8.DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+	resolved, err := playlist.Resolve()
+	require.NoError(t, err)
+	require.Len(t, resolved, 4)
+
+	decrypter := NewDecrypter(NewCachedKeyLoader(MemoryKeyLoader{"key1": key1, "key2": key2}))
+	ctx := context.Background()
+	plaintext := []byte("segment payload which spans more than one block")
+
+	decrypted, err := decrypter.DecryptSegment(ctx, resolved[0], encryptAES128(t, key1, m3u8.DefaultIV(7), plaintext))
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	iv := [16]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+	decrypted, err = decrypter.DecryptSegment(ctx, resolved[1], encryptAES128(t, key2, iv, plaintext))
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	decrypted, err = decrypter.DecryptSegment(ctx, resolved[2], plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	_, err = decrypter.DecryptSegment(ctx, resolved[3], plaintext)
+	assert.ErrorIs(t, err, ErrUnsupportedMethod)
+
+	t.Run("init section", func(t *testing.T) {
+		_, err := decrypter.DecryptInitSection(ctx, resolved[0], plaintext)
+		assert.ErrorIs(t, err, ErrMissingIV)
+		_, err = decrypter.DecryptInitSection(ctx, resolved[1], plaintext)
+		assert.ErrorIs(t, err, ErrMissingIV)
+
+		input := "#EXTM3U\n" +
+			"#EXT-X-TARGETDURATION:6\n" +
+			"#EXT-X-KEY:METHOD=AES-128,URI=\"key2\",IV=0x000102030405060708090A0B0C0D0E0F\n" +
+			"#EXT-X-MAP:URI=\"init.mp4\"\n" +
+			"#EXTINF:6.000,\n" +
+			"seg0.ts\n" +
+			"#EXT-X-KEY:METHOD=AES-128,URI=\"key1\"\n" +
+			"#EXTINF:6.000,\n" +
+			"seg1.ts\n" +
+			"#EXT-X-MAP:URI=\"init2.mp4\"\n" +
+			"#EXT-X-KEY:METHOD=AES-128,URI=\"key2\",IV=0x000102030405060708090A0B0C0D0E0F\n" +
+			"#EXTINF:6.000,\n" +
+			"seg2.ts\n" +
+			"#EXT-X-KEY:METHOD=AES-128,URI=\"key2\",IV=0x000102030405060708090A0B0C0D0E0F\n" +
+			"#EXT-X-MAP:URI=\"init3.mp4\"\n" +
+			"#EXTINF:6.000,\n" +
+			"seg3.ts\n"
+		playlist, err := m3u8.DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		resolved, err := playlist.Resolve()
+		require.NoError(t, err)
+		require.Len(t, resolved, 4)
+		for _, segment := range []*m3u8.ResolvedSegment{resolved[0], resolved[1], resolved[3]} {
+			decrypted, err := decrypter.DecryptInitSection(ctx, segment, encryptAES128(t, key2, iv, plaintext))
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, decrypted)
+		}
+		_, err = decrypter.DecryptInitSection(ctx, resolved[2], plaintext)
+		assert.ErrorIs(t, err, ErrMissingIV)
+	})
+
+	t.Run("init section before key", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-TARGETDURATION:6\n" +
+			"#EXT-X-MAP:URI=\"init.mp4\"\n" +
+			"#EXTINF:6.000,\n" +
+			"seg0.ts\n" +
+			"#EXT-X-KEY:METHOD=AES-128,URI=\"key1\",IV=0x000102030405060708090A0B0C0D0E0F\n" +
+			"#EXTINF:6.000,\n" +
+			"seg1.ts\n"
+		playlist, err := m3u8.DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		resolved, err := playlist.Resolve()
+		require.NoError(t, err)
+		require.Len(t, resolved, 2)
+		decrypted, err := decrypter.DecryptInitSection(ctx, resolved[1], plaintext)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+		decrypted, err = decrypter.DecryptSegment(ctx, resolved[1], encryptAES128(t, key1, iv, plaintext))
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("no identity key", func(t *testing.T) {
+		for _, key := range []string{
+			"#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"skd://key1\",KEYFORMAT=\"com.apple.streamingkeydelivery\"\n",
+			"#EXT-X-KEY:METHOD=AES-128,URI=\"key1\",KEYFORMAT=\"com.example.custom\"\n",
+		} {
+			input := "#EXTM3U\n" +
+				"#EXT-X-TARGETDURATION:6\n" +
+				key +
+				"#EXT-X-MAP:URI=\"init.mp4\"\n" +
+				"#EXTINF:6.000,\n" +
+				"seg0.ts\n"
+			playlist, err := m3u8.DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			resolved, err := playlist.Resolve()
+			require.NoError(t, err)
+			require.Len(t, resolved, 1)
+			_, err = decrypter.DecryptSegment(ctx, resolved[0], plaintext)
+			assert.ErrorIs(t, err, ErrUnsupportedMethod, key)
+			_, err = decrypter.DecryptInitSection(ctx, resolved[0], plaintext)
+			assert.ErrorIs(t, err, ErrUnsupportedMethod, key)
+		}
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		decrypter := NewDecrypter(MemoryKeyLoader{})
+		_, err := decrypter.DecryptSegment(ctx, resolved[0], encryptAES128(t, key1, m3u8.DefaultIV(7), plaintext))
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+}
+
+func TestDecryptAES128(t *testing.T) {
+	key := bytes.Repeat([]byte{0x01}, 16)
+	var iv [16]byte
+	for _, plaintext := range [][]byte{{}, []byte("0123456789abcdef"), []byte("short")} {
+		decrypted, err := DecryptAES128(key, iv, encryptAES128(t, key, iv, plaintext))
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	}
+
+	_, err := DecryptAES128(key[:8], iv, make([]byte, 16))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = DecryptAES128(key, iv, make([]byte, 15))
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	_, err = DecryptAES128(key, iv, nil)
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	_, err = DecryptAES128(bytes.Repeat([]byte{0x02}, 16), iv, encryptAES128(t, key, iv, []byte("0123456789abcdef")))
+	assert.ErrorIs(t, err, ErrInvalidPadding)
+}
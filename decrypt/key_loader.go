@@ -0,0 +1,128 @@
+package decrypt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// ErrKeyNotFound is returned when the key loader does not have the key.
+var ErrKeyNotFound = errors.New("key not found")
+
+// KeyLoader loads the key identified by the URI attribute of the EXT-X-KEY tag.
+type KeyLoader interface {
+	LoadKey(ctx context.Context, uri string) ([]byte, error)
+}
+
+// MemoryKeyLoader is a KeyLoader which holds the keys in memory, mapped by URI.
+type MemoryKeyLoader map[string][]byte
+
+// LoadKey returns the key of the URI.
+// If the key does not exist, it returns ErrKeyNotFound.
+func (loader MemoryKeyLoader) LoadKey(ctx context.Context, uri string) ([]byte, error) {
+	key, ok := loader[uri]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, uri)
+	}
+	return key, nil
+}
+
+// HTTPKeyLoader is a KeyLoader which fetches the keys over HTTP.
+type HTTPKeyLoader struct {
+	// Client is the HTTP client.
+	// If it is nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// BaseURL is the URL of the media playlist, which relative URIs are resolved against.
+	// If it is nil, URIs must be absolute.
+	BaseURL *url.URL
+
+	// MaxKeySize is the maximum size of a response body in bytes.
+	// If it is 0, DefaultMaxKeySize is used.
+	MaxKeySize int64
+}
+
+// DefaultMaxKeySize is the default value of HTTPKeyLoader.MaxKeySize.
+const DefaultMaxKeySize = 1024
+
+// LoadKey fetches the key from the URI.
+// If the server responds with a status other than 200, it returns an error.
+// If the status is 404, the error wraps ErrKeyNotFound.
+func (loader *HTTPKeyLoader) LoadKey(ctx context.Context, uri string) ([]byte, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if loader.BaseURL != nil {
+		u = loader.BaseURL.ResolveReference(u)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := loader.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, u)
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, u)
+	}
+	maxKeySize := loader.MaxKeySize
+	if maxKeySize <= 0 {
+		maxKeySize = DefaultMaxKeySize
+	}
+	key, err := io.ReadAll(io.LimitReader(resp.Body, maxKeySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(key)) > maxKeySize {
+		return nil, fmt.Errorf("key exceeds %d bytes: %s", maxKeySize, u)
+	}
+	return key, nil
+}
+
+// CachedKeyLoader is a KeyLoader which caches the keys loaded by the underlying KeyLoader.
+// It is safe for concurrent use if the underlying KeyLoader is.
+type CachedKeyLoader struct {
+	loader KeyLoader
+	mu     sync.Mutex
+	keys   map[string][]byte
+}
+
+// NewCachedKeyLoader creates a CachedKeyLoader.
+func NewCachedKeyLoader(loader KeyLoader) *CachedKeyLoader {
+	return &CachedKeyLoader{
+		loader: loader,
+		keys:   make(map[string][]byte),
+	}
+}
+
+// LoadKey returns the cached key of the URI, or loads it with the underlying KeyLoader.
+// Errors are not cached.
+func (loader *CachedKeyLoader) LoadKey(ctx context.Context, uri string) ([]byte, error) {
+	loader.mu.Lock()
+	key, ok := loader.keys[uri]
+	loader.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	key, err := loader.loader.LoadKey(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	loader.mu.Lock()
+	loader.keys[uri] = key
+	loader.mu.Unlock()
+	return key, nil
+}
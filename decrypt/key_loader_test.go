@@ -0,0 +1,86 @@
+package decrypt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryKeyLoader(t *testing.T) {
+	loader := MemoryKeyLoader{"key1": []byte("0123456789abcdef")}
+	key, err := loader.LoadKey(context.Background(), "key1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("0123456789abcdef"), key)
+	_, err = loader.LoadKey(context.Background(), "key2")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestHTTPKeyLoader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/keys/key1":
+			w.Write([]byte("0123456789abcdef"))
+		case "/keys/large":
+			w.Write(make([]byte, DefaultMaxKeySize+1))
+		case "/keys/error":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	baseURL, err := url.Parse(server.URL + "/media/playlist.m3u8")
+	require.NoError(t, err)
+	loader := &HTTPKeyLoader{BaseURL: baseURL}
+	ctx := context.Background()
+
+	key, err := loader.LoadKey(ctx, "../keys/key1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("0123456789abcdef"), key)
+
+	key, err = (&HTTPKeyLoader{Client: server.Client()}).LoadKey(ctx, server.URL+"/keys/key1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("0123456789abcdef"), key)
+
+	_, err = loader.LoadKey(ctx, "../keys/key2")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	_, err = loader.LoadKey(ctx, "../keys/error")
+	assert.Error(t, err)
+	_, err = loader.LoadKey(ctx, "../keys/large")
+	assert.Error(t, err)
+}
+
+type countingKeyLoader struct {
+	count int
+}
+
+func (loader *countingKeyLoader) LoadKey(ctx context.Context, uri string) ([]byte, error) {
+	loader.count++
+	if uri == "error" {
+		return nil, errors.New("failed")
+	}
+	return []byte(uri), nil
+}
+
+func TestCachedKeyLoader(t *testing.T) {
+	counter := &countingKeyLoader{}
+	loader := NewCachedKeyLoader(counter)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		key, err := loader.LoadKey(ctx, "key1")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("key1"), key)
+	}
+	assert.Equal(t, 1, counter.count)
+	for i := 0; i < 2; i++ {
+		_, err := loader.LoadKey(ctx, "error")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 3, counter.count)
+}
@@ -0,0 +1,230 @@
+package m3u8
+
+import (
+	"errors"
+)
+
+var (
+	// ErrSkipBoundaryNotSpecified is returned when the skip boundary of a delta update is unknown.
+	ErrSkipBoundaryNotSpecified = errors.New("skip boundary is not specified")
+
+	// ErrAlreadyDeltaUpdate is returned when the playlist already contains an EXT-X-SKIP tag.
+	ErrAlreadyDeltaUpdate = errors.New("playlist is already a delta update")
+
+	// ErrSkippedSegmentsUnavailable is returned when the segments skipped by a delta update
+	// are not available in the previous playlist.
+	ErrSkippedSegmentsUnavailable = errors.New("skipped segments are not available")
+
+	// ErrCannotSkipDateRanges is returned when a delta update skips EXT-X-DATERANGE tags
+	// but the EXT-X-SERVER-CONTROL tag does not have CAN-SKIP-DATERANGES=YES.
+	ErrCannotSkipDateRanges = errors.New("server does not support skipping date ranges")
+)
+
+const (
+	// skipVersion is the compatibility version required by the EXT-X-SKIP tag.
+	skipVersion = 9
+
+	// skipDateRangesVersion is the compatibility version required by a delta update
+	// which skips EXT-X-DATERANGE tags.
+	skipDateRangesVersion = 10
+)
+
+// DeltaUpdateOptions represents the options of MediaPlaylist#DeltaUpdate.
+type DeltaUpdateOptions struct {
+	// SkipBoundary is the skip boundary in seconds, measured from the end of the last segment.
+	// Segments which end at or before the skip boundary are replaced with an EXT-X-SKIP tag.
+	// If it is zero, the CAN-SKIP-UNTIL attribute of the EXT-X-SERVER-CONTROL tag is used.
+	SkipBoundary float64
+
+	// SkipDateRanges drops the EXT-X-DATERANGE tags of the skipped segments,
+	// which corresponds to the request with _HLS_skip=v2.
+	// It requires the EXT-X-SERVER-CONTROL tag with CAN-SKIP-DATERANGES=YES.
+	// If it is false, those tags are moved to the first remaining segment.
+	SkipDateRanges bool
+
+	// RecentlyRemovedDateRanges is a list of IDs of EXT-X-DATERANGE tags
+	// which have been removed from the playlist recently.
+	// It is written to the EXT-X-SKIP tag only when SkipDateRanges is true.
+	RecentlyRemovedDateRanges []string
+}
+
+// DeltaUpdate creates a delta update of the media playlist.
+// The values of EXT-X-MEDIA-SEQUENCE and EXT-X-DISCONTINUITY-SEQUENCE are kept
+// unchanged because they still refer to the first skipped segment.
+// If no segment precedes the skip boundary, the delta update has no EXT-X-SKIP tag.
+// Otherwise, EXT-X-VERSION of the delta update is raised to the version which EXT-X-SKIP requires.
+// The original playlist is not modified.
+func (playlist *MediaPlaylist) DeltaUpdate(opts DeltaUpdateOptions) (*MediaPlaylist, error) {
+	if playlist.Tags.Raw().First(TagExtXSkip) != nil {
+		return nil, ErrAlreadyDeltaUpdate
+	}
+	serverControl := playlist.Tags.ServerControl()
+	if opts.SkipDateRanges && !serverControl.CanSkipDateRanges() {
+		return nil, ErrCannotSkipDateRanges
+	}
+	boundary := opts.SkipBoundary
+	if boundary == 0 && serverControl != nil {
+		boundary, _ = serverControl.CanSkipUntil()
+	}
+	if boundary <= 0 {
+		return nil, ErrSkipBoundaryNotSpecified
+	}
+
+	var duration float64
+	skipped := len(playlist.Segments)
+	for skipped > 0 && duration < boundary {
+		skipped--
+		duration += playlist.Segments[skipped].Tags.ExtInfValue()
+	}
+
+	delta := &MediaPlaylist{
+		Tags:             MediaPlaylistTags(playlist.Tags.Raw().Clone()),
+		TagOrder:         cloneTagOrder(playlist.TagOrder),
+		positions:        cloneTagOrder(playlist.positions),
+		trailer:          cloneTagOrder(playlist.trailer),
+		registry:         playlist.registry,
+		Comments:         append([]string(nil), playlist.Comments...),
+		TrailingComments: append([]string(nil), playlist.TrailingComments...),
+		TrailingTags:     append([]*Tag(nil), playlist.TrailingTags...),
+		Segments:         make([]*Segment, 0, len(playlist.Segments)-skipped),
+		PreloadHints:     append([]*PreloadHint(nil), playlist.PreloadHints...),
+		RenditionReports: append([]*RenditionReport(nil), playlist.RenditionReports...),
+		EndList:          playlist.EndList,
+	}
+	for _, segment := range playlist.Segments[skipped:] {
+		delta.Segments = append(delta.Segments, segment.clone())
+	}
+	if playlist.PartialSegment != nil {
+		delta.PartialSegment = playlist.PartialSegment.clone()
+	}
+	if skipped == 0 {
+		return delta, nil
+	}
+
+	skip := make(SkipAttrs)
+	skip.SetSkippedSegments(int64(skipped))
+	version := skipVersion
+	if opts.SkipDateRanges {
+		skip.SetRecentlyRemovedDateRanges(opts.RecentlyRemovedDateRanges)
+		version = skipDateRangesVersion
+	} else {
+		var dateRanges []string
+		for _, segment := range playlist.Segments[:skipped] {
+			dateRanges = append(dateRanges, segment.Tags[TagExtXDateRange]...)
+		}
+		if len(dateRanges) != 0 {
+			first := delta.Segments[0]
+			first.Tags[TagExtXDateRange] = append(dateRanges, first.Tags[TagExtXDateRange]...)
+		}
+	}
+	delta.Tags.SetSkip(skip)
+	if delta.Tags.Version() < version {
+		delta.Tags.SetVersion(version)
+	}
+	return delta, nil
+}
+
+func (segment *Segment) clone() *Segment {
+	cloned := *segment
+	cloned.Tags = SegmentTags(segment.Tags.Raw().Clone())
+	cloned.Parts = append([]*Part(nil), segment.Parts...)
+	cloned.TagOrder = cloneTagOrder(segment.TagOrder)
+	cloned.positions = cloneTagOrder(segment.positions)
+	cloned.Comments = append([]string(nil), segment.Comments...)
+	return &cloned
+}
+
+func cloneTagOrder(order []string) []string {
+	if order == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(order)), order...)
+}
+
+// MergeDeltaUpdate rebuilds the full playlist from the delta update.
+// The skipped segments are recovered from the receiver by media sequence number.
+// If the receiver does not have all of them, it returns ErrSkippedSegmentsUnavailable,
+// and the client should reload the full playlist.
+// If the delta does not contain an EXT-X-SKIP tag, it is returned as is.
+// Neither the receiver nor the delta is modified.
+func (playlist *MediaPlaylist) MergeDeltaUpdate(delta *MediaPlaylist) (*MediaPlaylist, error) {
+	skip := delta.Tags.Skip()
+	if skip == nil {
+		return delta, nil
+	}
+	skipped, err := skip.SkippedSegments()
+	if err != nil {
+		return nil, err
+	}
+	start := delta.Tags.MediaSequence() - playlist.firstSequence()
+	if start < 0 || start+skipped > int64(len(playlist.Segments)) {
+		return nil, ErrSkippedSegmentsUnavailable
+	}
+
+	merged := &MediaPlaylist{
+		Tags:             MediaPlaylistTags(delta.Tags.Raw().Clone()),
+		TagOrder:         cloneTagOrder(delta.TagOrder),
+		positions:        cloneTagOrder(delta.positions),
+		trailer:          cloneTagOrder(delta.trailer),
+		registry:         delta.registry,
+		Comments:         append([]string(nil), delta.Comments...),
+		TrailingComments: append([]string(nil), delta.TrailingComments...),
+		TrailingTags:     append([]*Tag(nil), delta.TrailingTags...),
+		Segments:         make([]*Segment, 0, int(skipped)+len(delta.Segments)),
+		PreloadHints:     append([]*PreloadHint(nil), delta.PreloadHints...),
+		RenditionReports: append([]*RenditionReport(nil), delta.RenditionReports...),
+		EndList:          delta.EndList,
+	}
+	merged.Tags.RemoveSkip()
+	removed := make(map[string]struct{})
+	for _, id := range skip.RecentlyRemovedDateRanges() {
+		removed[id] = struct{}{}
+	}
+	recovered := make(map[string]struct{})
+	for _, segment := range playlist.Segments[start : start+skipped] {
+		segment = segment.clone()
+		if dateRanges, ok := segment.Tags[TagExtXDateRange]; ok {
+			kept := make([]string, 0, len(dateRanges))
+			for _, dateRange := range dateRanges {
+				attrs, err := ParseTagAttributes(dateRange)
+				if err == nil {
+					if _, ok := removed[DateRangeAttrs(attrs).EventID()]; ok {
+						continue
+					}
+				}
+				kept = append(kept, dateRange)
+				recovered[dateRange] = struct{}{}
+			}
+			if len(kept) != 0 {
+				segment.Tags[TagExtXDateRange] = kept
+			} else {
+				delete(segment.Tags, TagExtXDateRange)
+			}
+		}
+		merged.Segments = append(merged.Segments, segment)
+	}
+	for i, segment := range delta.Segments {
+		segment = segment.clone()
+		// DeltaUpdate moves the EXT-X-DATERANGE tags of the skipped segments
+		// to the first remaining segment, so they are removed here.
+		if dateRanges, ok := segment.Tags[TagExtXDateRange]; i == 0 && ok {
+			kept := make([]string, 0, len(dateRanges))
+			for _, dateRange := range dateRanges {
+				if _, ok := recovered[dateRange]; !ok {
+					kept = append(kept, dateRange)
+				}
+			}
+			if len(kept) != 0 {
+				segment.Tags[TagExtXDateRange] = kept
+			} else {
+				delete(segment.Tags, TagExtXDateRange)
+			}
+		}
+		merged.Segments = append(merged.Segments, segment)
+	}
+	if delta.PartialSegment != nil {
+		merged.PartialSegment = delta.PartialSegment.clone()
+	}
+	merged.setSequences()
+	return merged, nil
+}
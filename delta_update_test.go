@@ -0,0 +1,225 @@
+package m3u8
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sampleDeltaSourceInput = `#EXTM3U
+#EXT-X-VERSION:9
+#EXT-X-TARGETDURATION:4
+#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,CAN-SKIP-UNTIL=8,CAN-SKIP-DATERANGES=YES
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-DISCONTINUITY-SEQUENCE:10
+#EXT-X-DATERANGE:ID="ad1",START-DATE="2024-01-01T01:00:00.000Z",DURATION=4
+#EXTINF:4,
+segment100.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:4,
+segment101.ts
+#EXTINF:4,
+segment102.ts
+#EXT-X-DATERANGE:ID="ad2",START-DATE="2024-01-01T01:00:16.000Z",DURATION=4
+#EXTINF:4,
+segment103.ts
+#EXTINF:4,
+segment104.ts
+`
+
+var sampleDeltaOutput = `#EXTM3U
+#EXT-X-VERSION:9
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-DISCONTINUITY-SEQUENCE:10
+#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,CAN-SKIP-UNTIL=8,CAN-SKIP-DATERANGES=YES
+#EXT-X-SKIP:SKIPPED-SEGMENTS=3
+#EXT-X-DATERANGE:ID="ad1",START-DATE="2024-01-01T01:00:00.000Z",DURATION=4
+#EXT-X-DATERANGE:ID="ad2",START-DATE="2024-01-01T01:00:16.000Z",DURATION=4
+#EXTINF:4,
+segment103.ts
+#EXTINF:4,
+segment104.ts
+`
+
+var sampleDeltaV2Output = `#EXTM3U
+#EXT-X-VERSION:10
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-DISCONTINUITY-SEQUENCE:10
+#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,CAN-SKIP-UNTIL=8,CAN-SKIP-DATERANGES=YES
+#EXT-X-SKIP:RECENTLY-REMOVED-DATERANGES="ad0",SKIPPED-SEGMENTS=2
+#EXTINF:4,
+segment102.ts
+#EXT-X-DATERANGE:ID="ad2",START-DATE="2024-01-01T01:00:16.000Z",DURATION=4
+#EXTINF:4,
+segment103.ts
+#EXTINF:4,
+segment104.ts
+`
+
+func TestDeltaUpdate(t *testing.T) {
+	t.Run("server_control", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleDeltaSourceInput)))
+		require.NoError(t, err)
+		delta, err := playlist.DeltaUpdate(DeltaUpdateOptions{})
+		require.NoError(t, err)
+		require.Len(t, delta.Segments, 2)
+		assert.Equal(t, int64(103), delta.Segments[0].Sequence)
+		assert.Equal(t, int64(11), delta.Segments[0].DiscontinuitySequence)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, delta.Encode(w))
+		assert.Equal(t, sampleDeltaOutput, w.String())
+
+		// the original playlist is not modified
+		require.Len(t, playlist.Segments, 5)
+		assert.Len(t, playlist.Segments[3].Tags[TagExtXDateRange], 1)
+		assert.Nil(t, playlist.Tags.Skip())
+	})
+
+	t.Run("skip_date_ranges", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleDeltaSourceInput)))
+		require.NoError(t, err)
+		delta, err := playlist.DeltaUpdate(DeltaUpdateOptions{
+			SkipBoundary:              12,
+			SkipDateRanges:            true,
+			RecentlyRemovedDateRanges: []string{"ad0"},
+		})
+		require.NoError(t, err)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, delta.Encode(w))
+		assert.Equal(t, sampleDeltaV2Output, w.String())
+	})
+
+	t.Run("cannot_skip_date_ranges", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleDeltaSourceInput)))
+		require.NoError(t, err)
+		serverControl := playlist.Tags.ServerControl()
+		serverControl.SetCanSkipDateRanges(false)
+		playlist.Tags.SetServerControl(serverControl)
+		_, err = playlist.DeltaUpdate(DeltaUpdateOptions{SkipBoundary: 12, SkipDateRanges: true})
+		assert.ErrorIs(t, err, ErrCannotSkipDateRanges)
+	})
+
+	t.Run("version", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleDeltaSourceInput)))
+		require.NoError(t, err)
+		playlist.Tags.SetVersion(6)
+		delta, err := playlist.DeltaUpdate(DeltaUpdateOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 9, delta.Tags.Version())
+		delta, err = playlist.DeltaUpdate(DeltaUpdateOptions{SkipDateRanges: true})
+		require.NoError(t, err)
+		assert.Equal(t, 10, delta.Tags.Version())
+		delta, err = playlist.DeltaUpdate(DeltaUpdateOptions{SkipBoundary: 20})
+		require.NoError(t, err)
+		assert.Equal(t, 6, delta.Tags.Version())
+		assert.Equal(t, 6, playlist.Tags.Version())
+	})
+
+	t.Run("nothing_to_skip", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleDeltaSourceInput)))
+		require.NoError(t, err)
+		delta, err := playlist.DeltaUpdate(DeltaUpdateOptions{SkipBoundary: 20})
+		require.NoError(t, err)
+		assert.Nil(t, delta.Tags.Skip())
+		assert.Len(t, delta.Segments, 5)
+	})
+
+	t.Run("no_skip_boundary", func(t *testing.T) {
+		playlist := &MediaPlaylist{Tags: make(MediaPlaylistTags)}
+		_, err := playlist.DeltaUpdate(DeltaUpdateOptions{})
+		assert.ErrorIs(t, err, ErrSkipBoundaryNotSpecified)
+	})
+
+	t.Run("already_delta_update", func(t *testing.T) {
+		playlist := &MediaPlaylist{Tags: MediaPlaylistTags{"EXT-X-SKIP": []string{"SKIPPED-SEGMENTS=3"}}}
+		_, err := playlist.DeltaUpdate(DeltaUpdateOptions{SkipBoundary: 12})
+		assert.ErrorIs(t, err, ErrAlreadyDeltaUpdate)
+	})
+}
+
+func TestMergeDeltaUpdate(t *testing.T) {
+	decodeDelta := func(t *testing.T, source *MediaPlaylist, opts DeltaUpdateOptions) *MediaPlaylist {
+		delta, err := source.DeltaUpdate(opts)
+		require.NoError(t, err)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, delta.Encode(w))
+		decoded, err := DecodeMediaPlaylist(w)
+		require.NoError(t, err)
+		return decoded
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		source, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleDeltaSourceInput)))
+		require.NoError(t, err)
+		delta := decodeDelta(t, source, DeltaUpdateOptions{})
+		require.Len(t, delta.Segments, 2)
+		assert.Equal(t, int64(103), delta.Segments[0].Sequence)
+		assert.Equal(t, int64(104), delta.Segments[1].Sequence)
+
+		merged, err := source.MergeDeltaUpdate(delta)
+		require.NoError(t, err)
+		require.Len(t, merged.Segments, 5)
+		for i, segment := range merged.Segments {
+			assert.Equal(t, source.Segments[i].Sequence, segment.Sequence)
+			assert.Equal(t, source.Segments[i].DiscontinuitySequence, segment.DiscontinuitySequence)
+		}
+		expected := bytes.NewBuffer(nil)
+		require.NoError(t, source.Encode(expected))
+		actual := bytes.NewBuffer(nil)
+		require.NoError(t, merged.Encode(actual))
+		assert.Equal(t, expected.String(), actual.String())
+	})
+
+	t.Run("recently_removed_date_ranges", func(t *testing.T) {
+		source, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleDeltaSourceInput)))
+		require.NoError(t, err)
+		delta := decodeDelta(t, source, DeltaUpdateOptions{
+			SkipDateRanges:            true,
+			RecentlyRemovedDateRanges: []string{"ad1"},
+		})
+		merged, err := source.MergeDeltaUpdate(delta)
+		require.NoError(t, err)
+		require.Len(t, merged.Segments, 5)
+		assert.Empty(t, merged.Segments[0].Tags[TagExtXDateRange])
+		assert.Len(t, merged.Segments[3].Tags[TagExtXDateRange], 1)
+		assert.Nil(t, merged.Tags.Skip())
+		assert.Len(t, source.Segments[0].Tags[TagExtXDateRange], 1)
+	})
+
+	t.Run("advanced_media_sequence", func(t *testing.T) {
+		source, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleDeltaSourceInput)))
+		require.NoError(t, err)
+		delta := decodeDelta(t, source, DeltaUpdateOptions{})
+		delta.Tags.SetMediaSequence(101)
+		delta.Tags.SetSkip(SkipAttrs{"SKIPPED-SEGMENTS": "2"})
+		merged, err := source.MergeDeltaUpdate(delta)
+		require.NoError(t, err)
+		require.Len(t, merged.Segments, 4)
+		assert.Equal(t, "segment101.ts", merged.Segments[0].URI)
+		assert.Equal(t, int64(101), merged.Segments[0].Sequence)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		source, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleDeltaSourceInput)))
+		require.NoError(t, err)
+		delta := decodeDelta(t, source, DeltaUpdateOptions{})
+		delta.Tags.SetMediaSequence(99)
+		_, err = source.MergeDeltaUpdate(delta)
+		assert.ErrorIs(t, err, ErrSkippedSegmentsUnavailable)
+		delta.Tags.SetMediaSequence(103)
+		_, err = source.MergeDeltaUpdate(delta)
+		assert.ErrorIs(t, err, ErrSkippedSegmentsUnavailable)
+	})
+
+	t.Run("full_playlist", func(t *testing.T) {
+		source, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleDeltaSourceInput)))
+		require.NoError(t, err)
+		merged, err := source.MergeDeltaUpdate(source)
+		require.NoError(t, err)
+		assert.Same(t, source, merged)
+	})
+}
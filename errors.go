@@ -0,0 +1,109 @@
+package m3u8
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidAttributes is returned when the attribute list of a tag is invalid.
+	ErrInvalidAttributes = errors.New("invalid HLS tag attributes")
+
+	// ErrInvalidStreamInf is returned when an EXT-X-STREAM-INF tag is not followed by a URI.
+	// DecodeMasterPlaylist returns it with the master playlist decoded so far,
+	// which does not include the stream of the tag.
+	ErrInvalidStreamInf = errors.New("invalid EXT-X-STREAM-INF tag")
+
+	// ErrMissingGroupID is returned when an EXT-X-MEDIA tag has no GROUP-ID attribute.
+	ErrMissingGroupID = errors.New("missing GROUP-ID")
+
+	// ErrMissingMethod is returned when an EXT-X-KEY or EXT-X-SESSION-KEY tag has no METHOD attribute.
+	ErrMissingMethod = errors.New("missing METHOD")
+
+	// ErrInvalidMediaType is returned when an EXT-X-MEDIA tag has an invalid TYPE attribute.
+	ErrInvalidMediaType = errors.New("invalid TYPE")
+
+	// ErrLimitExceeded matches any *LimitError with errors.Is.
+	ErrLimitExceeded = errors.New("limit exceeded")
+)
+
+// DecodeError represents an error which occurs at a specific line while decoding a playlist.
+// The cause can be tested with errors.Is.
+type DecodeError struct {
+	// Line is the 1-based line number.
+	// It is 0 if the line number is unknown.
+	Line int
+
+	// Raw is the raw line.
+	Raw string
+
+	// Tag is the name of the tag.
+	// It is empty if the line is not a tag.
+	Tag string
+
+	// Err is the cause of the error.
+	Err error
+}
+
+func newDecodeError(line int, raw string, tag string, err error) *DecodeError {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		err = decodeErr.Err
+	}
+	return &DecodeError{
+		Line: line,
+		Raw:  raw,
+		Tag:  tag,
+		Err:  err,
+	}
+}
+
+// Error returns the error message.
+func (e *DecodeError) Error() string {
+	var b strings.Builder
+	if e.Line != 0 {
+		fmt.Fprintf(&b, "line %d: ", e.Line)
+	}
+	if e.Tag != "" {
+		b.WriteString(e.Tag)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+	if e.Raw != "" {
+		fmt.Fprintf(&b, ": %q", e.Raw)
+	}
+	return b.String()
+}
+
+// Unwrap returns the cause of the error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// LimitError is returned when a playlist exceeds a limit of DecodeOptions.
+type LimitError struct {
+	// Limit is the name of the DecodeOptions field which is exceeded,
+	// such as "MaxLineLength" or "MaxSegments".
+	Limit string
+
+	// Max is the value of the limit.
+	Max int64
+
+	// Line is the 1-based line number where the limit is exceeded.
+	// It is 0 if the line number is unknown.
+	Line int
+}
+
+// Error returns the error message.
+func (e *LimitError) Error() string {
+	if e.Line != 0 {
+		return fmt.Sprintf("line %d: %s exceeded: %d", e.Line, e.Limit, e.Max)
+	}
+	return fmt.Sprintf("%s exceeded: %d", e.Limit, e.Max)
+}
+
+// Is reports whether the target is ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
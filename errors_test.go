@@ -0,0 +1,52 @@
+package m3u8
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeError(t *testing.T) {
+	t.Run("Error", func(t *testing.T) {
+		err := &DecodeError{
+			Line: 3,
+			Raw:  "#EXT-X-MEDIA:TYPE=AUDIO",
+			Tag:  "EXT-X-MEDIA",
+			Err:  ErrMissingGroupID,
+		}
+		assert.Equal(t, `line 3: EXT-X-MEDIA: missing GROUP-ID: "#EXT-X-MEDIA:TYPE=AUDIO"`, err.Error())
+		assert.ErrorIs(t, err, ErrMissingGroupID)
+		assert.Equal(t, "invalid HLS tag attributes", (&DecodeError{Err: ErrInvalidAttributes}).Error())
+	})
+
+	t.Run("ParseTagAttributes", func(t *testing.T) {
+		_, err := ParseTagAttributes(`FOO="bar`)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidAttributes)
+		var decodeErr *DecodeError
+		require.True(t, errors.As(err, &decodeErr))
+		assert.Equal(t, `FOO="bar`, decodeErr.Raw)
+		assert.Zero(t, decodeErr.Line)
+	})
+
+	t.Run("newDecodeError", func(t *testing.T) {
+		_, cause := ParseTagAttributes(`FOO="bar`)
+		err := newDecodeError(5, `#EXT-X-PART:FOO="bar`, "EXT-X-PART", cause)
+		assert.Equal(t, &DecodeError{
+			Line: 5,
+			Raw:  `#EXT-X-PART:FOO="bar`,
+			Tag:  "EXT-X-PART",
+			Err:  ErrInvalidAttributes,
+		}, err)
+	})
+}
+
+func TestLimitError(t *testing.T) {
+	err := &LimitError{Limit: "MaxSegments", Max: 100, Line: 12}
+	assert.Equal(t, "line 12: MaxSegments exceeded: 100", err.Error())
+	assert.Equal(t, "MaxInputSize exceeded: 1024", (&LimitError{Limit: "MaxInputSize", Max: 1024}).Error())
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.NotErrorIs(t, err, ErrInvalidAttributes)
+}
@@ -0,0 +1,158 @@
+package m3u8
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// TokenType represents the type of a line in a playlist.
+type TokenType int
+
+const (
+	// TokenBlank represents an empty line.
+	TokenBlank TokenType = iota
+	// TokenHeader represents the EXTM3U tag.
+	TokenHeader
+	// TokenTag represents a line which starts with "#EXT".
+	TokenTag
+	// TokenURI represents a line which does not start with "#".
+	TokenURI
+	// TokenComment represents a line which starts with "#" but not with "#EXT".
+	TokenComment
+)
+
+// String returns the name of the token type.
+func (t TokenType) String() string {
+	switch t {
+	case TokenBlank:
+		return "Blank"
+	case TokenHeader:
+		return "Header"
+	case TokenTag:
+		return "Tag"
+	case TokenURI:
+		return "URI"
+	case TokenComment:
+		return "Comment"
+	}
+	return "Unknown"
+}
+
+// Token represents a line in a playlist.
+type Token struct {
+	// Type is the type of the line.
+	Type TokenType
+
+	// Line is the 1-based line number.
+	Line int
+
+	// Raw is the line without the line terminator.
+	Raw string
+
+	// Name is the tag name of TokenHeader and TokenTag.
+	Name string
+
+	// Attributes is the raw attribute string of TokenTag.
+	// See ParseTagAttributes.
+	Attributes string
+}
+
+// NewToken classifies a line by the same rules as the decoders.
+func NewToken(line string, lineNumber int) Token {
+	token := Token{Line: lineNumber, Raw: line}
+	switch {
+	case line == "":
+		token.Type = TokenBlank
+	case line[0] != '#':
+		token.Type = TokenURI
+	case !strings.HasPrefix(line, "#EXT"):
+		token.Type = TokenComment
+	default:
+		token.Name = TagName(line)
+		token.Attributes = AttributeString(line)
+		if token.Name == TagExtM3U {
+			token.Type = TokenHeader
+		} else {
+			token.Type = TokenTag
+		}
+	}
+	return token
+}
+
+// Lexer splits a playlist into tokens line by line.
+type Lexer struct {
+	opts       *DecodeOptions
+	scanner    *bufio.Scanner
+	lineNumber int
+	err        error
+}
+
+// NewLexer creates a Lexer which reads from io.Reader.
+// Only MaxLineLength and MaxInputSize of the options are applied.
+func NewLexer(r io.Reader, opts *DecodeOptions) *Lexer {
+	if opts == nil {
+		opts = &DecodeOptions{}
+	}
+	if opts.MaxInputSize > 0 {
+		r = &inputSizeLimiter{r: r, remaining: opts.MaxInputSize, max: opts.MaxInputSize}
+	}
+	scanner := bufio.NewScanner(r)
+	// The buffer has room for CRLF so that the length of the line itself can be checked.
+	max := opts.maxLineLength() + 2
+	scanner.Buffer(make([]byte, 0, min(max, 4096)), max)
+	return &Lexer{opts: opts, scanner: scanner}
+}
+
+// Next returns the next token.
+// It returns io.EOF at the end of the input, and a *LimitError when the input exceeds
+// MaxLineLength or MaxInputSize.
+func (l *Lexer) Next() (Token, error) {
+	if l.err != nil {
+		return Token{}, l.err
+	}
+	if !l.scanner.Scan() {
+		l.err = l.scanner.Err()
+		if l.err == nil {
+			l.err = io.EOF
+		} else if errors.Is(l.err, bufio.ErrTooLong) {
+			l.err = l.lineLengthError(l.lineNumber + 1)
+		}
+		return Token{}, l.err
+	}
+	l.lineNumber++
+	if len(l.scanner.Bytes()) > l.opts.maxLineLength() {
+		l.err = l.lineLengthError(l.lineNumber)
+		return Token{}, l.err
+	}
+	return NewToken(l.scanner.Text(), l.lineNumber), nil
+}
+
+func (l *Lexer) lineLengthError(lineNumber int) error {
+	return &LimitError{Limit: "MaxLineLength", Max: int64(l.opts.maxLineLength()), Line: lineNumber}
+}
+
+// inputSizeLimiter is an io.Reader which fails with a *LimitError
+// when the underlying reader provides more than max bytes.
+type inputSizeLimiter struct {
+	r         io.Reader
+	remaining int64
+	max       int64
+}
+
+func (l *inputSizeLimiter) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, &LimitError{Limit: "MaxInputSize", Max: l.max}
+	}
+	// Reads one more byte than the limit to detect an excess.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return 0, &LimitError{Limit: "MaxInputSize", Max: l.max}
+	}
+	return n, err
+}
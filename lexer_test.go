@@ -0,0 +1,75 @@
+package m3u8
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexer(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"# generated by packager\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		"\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\"\n" +
+		"#EXTINF:6.000,\n" +
+		"seg0.ts\n" +
+		"#EXT-X-ENDLIST\n"
+	lexer := NewLexer(bytes.NewReader([]byte(input)), nil)
+	var tokens []Token
+	for {
+		token, err := lexer.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		tokens = append(tokens, token)
+	}
+	assert.Equal(t, []Token{
+		{Type: TokenHeader, Line: 1, Raw: "#EXTM3U", Name: "EXTM3U"},
+		{Type: TokenComment, Line: 2, Raw: "# generated by packager"},
+		{Type: TokenTag, Line: 3, Raw: "#EXT-X-TARGETDURATION:6", Name: "EXT-X-TARGETDURATION", Attributes: "6"},
+		{Type: TokenBlank, Line: 4},
+		{Type: TokenTag, Line: 5, Raw: "#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\"", Name: "EXT-X-KEY", Attributes: "METHOD=AES-128,URI=\"key.bin\""},
+		{Type: TokenTag, Line: 6, Raw: "#EXTINF:6.000,", Name: "EXTINF", Attributes: "6.000,"},
+		{Type: TokenURI, Line: 7, Raw: "seg0.ts"},
+		{Type: TokenTag, Line: 8, Raw: "#EXT-X-ENDLIST", Name: "EXT-X-ENDLIST"},
+	}, tokens)
+
+	_, err := lexer.Next()
+	assert.Equal(t, io.EOF, err)
+
+	t.Run("crlf", func(t *testing.T) {
+		lexer := NewLexer(bytes.NewReader([]byte("#EXTM3U\r\nseg0.ts\r\n")), nil)
+		token, err := lexer.Next()
+		require.NoError(t, err)
+		assert.Equal(t, TokenHeader, token.Type)
+		token, err = lexer.Next()
+		require.NoError(t, err)
+		assert.Equal(t, Token{Type: TokenURI, Line: 2, Raw: "seg0.ts"}, token)
+	})
+
+	t.Run("max_line_length", func(t *testing.T) {
+		lexer := NewLexer(bytes.NewReader([]byte("#EXTM3U\n#EXT-X-TARGETDURATION:6\n")), &DecodeOptions{MaxLineLength: 10})
+		_, err := lexer.Next()
+		require.NoError(t, err)
+		_, err = lexer.Next()
+		var limitErr *LimitError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, 2, limitErr.Line)
+		_, err = lexer.Next()
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+	})
+}
+
+func TestTokenType(t *testing.T) {
+	assert.Equal(t, "Blank", TokenBlank.String())
+	assert.Equal(t, "Header", TokenHeader.String())
+	assert.Equal(t, "Tag", TokenTag.String())
+	assert.Equal(t, "URI", TokenURI.String())
+	assert.Equal(t, "Comment", TokenComment.String())
+	assert.Equal(t, "Unknown", TokenType(-1).String())
+}
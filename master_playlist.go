@@ -1,11 +1,9 @@
 package m3u8
 
 import (
-	"bufio"
-	"errors"
 	"fmt"
 	"io"
-	"strings"
+	"sort"
 )
 
 // MasterPlaylist represents a master playlist.
@@ -14,6 +12,11 @@ type MasterPlaylist struct {
 	// This list does not include stream tags.
 	Tags Tags
 
+	// TagOrder is the order of the names of Tags. See DecodeOptions.PreserveTagOrder.
+	// EXT-X-MEDIA, EXT-X-STREAM-INF and EXT-X-I-FRAME-STREAM-INF in TagOrder mark
+	// the positions of Alternatives, Streams and IFrameStreams respectively.
+	TagOrder []string
+
 	// Streams is a list of variant streams.
 	Streams []*Stream
 
@@ -22,6 +25,28 @@ type MasterPlaylist struct {
 
 	// IFrameStreams is a list of I-frame streams.
 	IFrameStreams []*Stream
+
+	// Comments is a list of comments among the tags of the master playlist. See DecodeOptions.PreserveTagOrder.
+	Comments []string
+
+	// TrailingComments is a list of comments at the end of the master playlist.
+	// Encode writes them at the end of the master playlist.
+	TrailingComments []string
+
+	// Warnings is a list of problems found by DecodeMasterPlaylistWithOptions in DecodeModeLenient.
+	Warnings []error
+
+	// alternativeOrder is the order in which the alternatives were decoded.
+	// This field is set by DecodeMasterPlaylistWithOptions when PreserveTagOrder is enabled.
+	alternativeOrder []*Alternative
+
+	// positions is the order of the names of Tags in which they were decoded.
+	// When TagOrder is nil, Encode uses it to write Comments where they were.
+	positions []string
+
+	// registry is the TagRegistry used to decode the master playlist.
+	// Encode sorts the tags by the orders in it.
+	registry *TagRegistry
 }
 
 // Stream represents a variant stream.
@@ -29,8 +54,17 @@ type Stream struct {
 	// Attributes is a list of attributes in the stream.
 	Attributes StreamInfAttrs
 
+	// AttributeOrder is the order of the keys of Attributes. See DecodeOptions.PreserveAttributeOrder.
+	AttributeOrder []string
+
 	// URI is the URI of the media playlist.
 	URI string
+
+	// Comments is a list of comments which precede the stream. See DecodeOptions.PreserveTagOrder.
+	Comments []string
+
+	// URIComments is a list of comments between the EXT-X-STREAM-INF tag and the URI. See DecodeOptions.PreserveTagOrder.
+	URIComments []string
 }
 
 type Alternatives struct {
@@ -38,18 +72,124 @@ type Alternatives struct {
 	Audio          map[string][]*Alternative
 	Subtitles      map[string][]*Alternative
 	ClosedCaptions map[string][]*Alternative
+
+	// GroupOrder is the order of the groups of alternative renditions.
+	// DecodeMasterPlaylist sets it in the order in which the groups appear.
+	// Encode writes the groups in this order, and then writes the groups
+	// which are not listed here sorted by type and group ID.
+	GroupOrder []AlternativeGroup
+}
+
+// AlternativeGroup identifies a group of alternative renditions.
+type AlternativeGroup struct {
+	Type    MediaType
+	GroupID string
+}
+
+var alternativeTypes = []MediaType{
+	MediaTypeVideo,
+	MediaTypeAudio,
+	MediaTypeSubtitles,
+	MediaTypeClosedCaptions,
+}
+
+func (alts *Alternatives) groupMap(typ MediaType) map[string][]*Alternative {
+	switch typ {
+	case MediaTypeVideo:
+		return alts.Video
+	case MediaTypeAudio:
+		return alts.Audio
+	case MediaTypeSubtitles:
+		return alts.Subtitles
+	case MediaTypeClosedCaptions:
+		return alts.ClosedCaptions
+	}
+	return nil
+}
+
+// Groups returns the groups in the order in which Encode writes them.
+func (alts *Alternatives) Groups() []AlternativeGroup {
+	groups := make([]AlternativeGroup, 0, len(alts.GroupOrder))
+	listed := make(map[AlternativeGroup]struct{}, len(alts.GroupOrder))
+	for _, group := range alts.GroupOrder {
+		if _, ok := listed[group]; ok {
+			continue
+		}
+		if _, ok := alts.groupMap(group.Type)[group.GroupID]; !ok {
+			continue
+		}
+		listed[group] = struct{}{}
+		groups = append(groups, group)
+	}
+	for _, typ := range alternativeTypes {
+		groupIDs := make([]string, 0)
+		for groupID := range alts.groupMap(typ) {
+			if _, ok := listed[AlternativeGroup{Type: typ, GroupID: groupID}]; !ok {
+				groupIDs = append(groupIDs, groupID)
+			}
+		}
+		sort.Strings(groupIDs)
+		for _, groupID := range groupIDs {
+			groups = append(groups, AlternativeGroup{Type: typ, GroupID: groupID})
+		}
+	}
+	return groups
+}
+
+// Renditions returns the alternative renditions in the group.
+func (alts *Alternatives) Renditions(group AlternativeGroup) []*Alternative {
+	return alts.groupMap(group.Type)[group.GroupID]
+}
+
+// SortGroups sorts the groups by less and updates GroupOrder.
+func (alts *Alternatives) SortGroups(less func(a, b AlternativeGroup) bool) {
+	groups := alts.Groups()
+	sort.SliceStable(groups, func(i, j int) bool {
+		return less(groups[i], groups[j])
+	})
+	alts.GroupOrder = groups
+}
+
+// SortRenditions sorts the alternative renditions in each group by less.
+func (alts *Alternatives) SortRenditions(less func(a, b *Alternative) bool) {
+	for _, typ := range alternativeTypes {
+		for _, alternatives := range alts.groupMap(typ) {
+			sort.SliceStable(alternatives, func(i, j int) bool {
+				return less(alternatives[i], alternatives[j])
+			})
+		}
+	}
 }
 
 type Alternative struct {
 	// Attributes is a list of attributes in the alternative.
 	Attributes MediaAttrs
+
+	// AttributeOrder is the order of the keys of Attributes. See DecodeOptions.PreserveAttributeOrder.
+	AttributeOrder []string
+
+	// Comments is a list of comments which precede the EXT-X-MEDIA tag. See DecodeOptions.PreserveTagOrder.
+	Comments []string
 }
 
 // DecodeMasterPlaylist decodes a master playlist from io.Reader.
+// If it fails, it returns the error with the master playlist decoded so far,
+// which does not include the element of the line where the error occurred.
 func DecodeMasterPlaylist(r io.Reader) (*MasterPlaylist, error) {
-	scanner := bufio.NewScanner(r)
+	return DecodeMasterPlaylistWithOptions(r, nil)
+}
+
+// DecodeMasterPlaylistWithOptions decodes a master playlist from io.Reader with the options.
+func DecodeMasterPlaylistWithOptions(r io.Reader, opts *DecodeOptions) (*MasterPlaylist, error) {
+	d := newDecodeState(opts)
+	opts = d.opts
+	lexer := NewLexer(r, opts)
 	var playlist MasterPlaylist
+	playlist.registry = opts.tagRegistry()
 	playlist.Tags = make(Tags)
+	if opts.PreserveTagOrder {
+		playlist.TagOrder = make([]string, 0)
+	}
 	playlist.Streams = make([]*Stream, 0)
 	playlist.Alternatives = Alternatives{
 		Video:          make(map[string][]*Alternative),
@@ -58,144 +198,388 @@ func DecodeMasterPlaylist(r io.Reader) (*MasterPlaylist, error) {
 		ClosedCaptions: make(map[string][]*Alternative),
 	}
 	var streamInfAttrs StreamInfAttrs
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
+	var streamInfLine int
+	var streamInfRaw string
+	var streamInfAttrOrder []string
+	var streamComments []string
+	// comments are the comments which are not attached to the playlist or an element yet.
+	var comments []string
+	// tags is the number of tags and comments counted toward MaxPlaylistTags.
+	var tags int
+	// addTagOrder records the name of a tag of the master playlist to TagOrder
+	// with PreserveTagOrder, or to positions without it.
+	addTagOrder := func(name string) {
+		if opts.PreserveTagOrder {
+			playlist.TagOrder = append(playlist.TagOrder, name)
+		} else {
+			playlist.positions = append(playlist.positions, name)
+		}
+	}
+	// fail returns the error with the master playlist decoded so far.
+	fail := func(err error) (*MasterPlaylist, error) {
+		playlist.Warnings = d.warnings
+		return &playlist, err
+	}
+	attachComments := func() {
+		playlist.Comments = append(playlist.Comments, comments...)
+		for range comments {
+			addTagOrder(CommentMarker)
+		}
+		comments = nil
+	}
+	for {
+		token, err := lexer.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fail(err)
+		}
+		lineNumber := token.Line
+		line := token.Raw
+		if err := d.checkHeader(token); err != nil {
+			return fail(err)
+		}
+		if token.Type == TokenBlank {
 			continue
 		}
-		tagName := TagName(line)
-		if tagName == "" {
+		if token.Type != TokenURI {
+			tags++
+			if err := d.checkCount(lineNumber, "MaxPlaylistTags", tags, opts.MaxPlaylistTags); err != nil {
+				return fail(err)
+			}
+		}
+		tagName := token.Name
+		if token.Type == TokenComment {
+			comments = append(comments, line[1:])
+			continue
+		}
+		if token.Type != TokenURI && streamInfAttrs != nil {
+			if err := d.report(newDecodeError(streamInfLine, streamInfRaw, TagExtXStreamInf, ErrInvalidStreamInf), true); err != nil {
+				return fail(err)
+			}
+			// The EXT-X-STREAM-INF tag is skipped, and its comments are kept for the next element.
+			streamInfAttrs = nil
+			streamInfAttrOrder = nil
+			comments = append(streamComments, comments...)
+			streamComments = nil
+			if opts.PreserveTagOrder {
+				playlist.TagOrder = removeLastTag(playlist.TagOrder, TagExtXStreamInf)
+			}
+		}
+		if token.Type == TokenURI {
+			if d.validates() && streamInfAttrs == nil {
+				if err := d.report(newDecodeError(lineNumber, line, "", ErrMissingStreamInf), false); err != nil {
+					return fail(err)
+				}
+			}
 			playlist.Streams = append(playlist.Streams, &Stream{
-				Attributes: streamInfAttrs,
-				URI:        line,
+				Attributes:     streamInfAttrs,
+				AttributeOrder: streamInfAttrOrder,
+				URI:            line,
+				Comments:       streamComments,
+				URIComments:    comments,
 			})
 			streamInfAttrs = nil
-		} else if streamInfAttrs != nil {
-			return nil, errors.New("invalid EXT-X-STREAM-INF tag")
+			streamInfAttrOrder = nil
+			streamComments = nil
+			comments = nil
 		} else if tagName == TagExtXStreamInf {
-			attrs, err := ParseTagAttributes(AttributeString(line))
+			attrs, attrOrder, err := opts.parseTagAttributes(AttributeString(line))
 			if err != nil {
-				return nil, err
+				if err := d.report(newDecodeError(lineNumber, line, tagName, err), true); err != nil {
+					return fail(err)
+				}
+				continue
+			}
+			if err := d.checkAttributes(lineNumber, line, tagName, StreamInfAttrs(attrs).Validate); err != nil {
+				return fail(err)
 			}
 			streamInfAttrs = StreamInfAttrs(attrs)
+			streamInfLine = lineNumber
+			streamInfRaw = line
+			streamInfAttrOrder = attrOrder
+			streamComments = comments
+			comments = nil
+			if opts.PreserveTagOrder {
+				playlist.TagOrder = append(playlist.TagOrder, tagName)
+			}
 		} else if tagName == TagExtXIFrameStreamInf {
-			attrs, err := ParseTagAttributes(AttributeString(line))
+			attrs, attrOrder, err := opts.parseTagAttributes(AttributeString(line))
 			if err != nil {
-				return nil, err
+				if err := d.report(newDecodeError(lineNumber, line, tagName, err), true); err != nil {
+					return fail(err)
+				}
+				continue
+			}
+			if err := d.checkAttributes(lineNumber, line, tagName, StreamInfAttrs(attrs).Validate); err != nil {
+				return fail(err)
 			}
-			uri := strings.Trim(attrs["URI"], "\"")
+			uri := AttributeValue(attrs["URI"]).unquote()
 			delete(attrs, "URI")
 			playlist.IFrameStreams = append(playlist.IFrameStreams, &Stream{
-				Attributes: StreamInfAttrs(attrs),
-				URI:        uri,
+				Attributes:     StreamInfAttrs(attrs),
+				AttributeOrder: attrOrder,
+				URI:            uri,
+				Comments:       comments,
 			})
+			comments = nil
+			if opts.PreserveTagOrder {
+				playlist.TagOrder = append(playlist.TagOrder, tagName)
+			}
 		} else if tagName == TagExtXMedia {
-			attrs, err := ParseTagAttributes(AttributeString(line))
+			attrs, attrOrder, err := opts.parseTagAttributes(AttributeString(line))
 			if err != nil {
-				return nil, err
+				if err := d.report(newDecodeError(lineNumber, line, tagName, err), true); err != nil {
+					return fail(err)
+				}
+				continue
 			}
-			groupID := strings.Trim(attrs["GROUP-ID"], `"`)
-			if groupID == "" {
-				return nil, errors.New("missing GROUP-ID")
+			if err := d.checkAttributes(lineNumber, line, tagName, MediaAttrs(attrs).Validate); err != nil {
+				return fail(err)
 			}
-			typ := attrs["TYPE"]
-			switch MediaType(typ) {
-			case MediaTypeVideo:
-				if _, ok := playlist.Alternatives.Video[groupID]; !ok {
-					playlist.Alternatives.Video[groupID] = make([]*Alternative, 0)
-				}
-				playlist.Alternatives.Video[groupID] = append(playlist.Alternatives.Video[groupID], &Alternative{
-					Attributes: MediaAttrs(attrs),
-				})
-			case MediaTypeAudio:
-				if _, ok := playlist.Alternatives.Audio[groupID]; !ok {
-					playlist.Alternatives.Audio[groupID] = make([]*Alternative, 0)
-				}
-				playlist.Alternatives.Audio[groupID] = append(playlist.Alternatives.Audio[groupID], &Alternative{
-					Attributes: MediaAttrs(attrs),
-				})
-			case MediaTypeSubtitles:
-				if _, ok := playlist.Alternatives.Subtitles[groupID]; !ok {
-					playlist.Alternatives.Subtitles[groupID] = make([]*Alternative, 0)
+			groupID := AttributeValue(attrs["GROUP-ID"]).unquote()
+			if groupID == "" {
+				if err := d.report(newDecodeError(lineNumber, line, tagName, ErrMissingGroupID), true); err != nil {
+					return fail(err)
 				}
-				playlist.Alternatives.Subtitles[groupID] = append(playlist.Alternatives.Subtitles[groupID], &Alternative{
-					Attributes: MediaAttrs(attrs),
-				})
-			case MediaTypeClosedCaptions:
-				if _, ok := playlist.Alternatives.ClosedCaptions[groupID]; !ok {
-					playlist.Alternatives.ClosedCaptions[groupID] = make([]*Alternative, 0)
+				continue
+			}
+			group := AlternativeGroup{Type: MediaType(attrs["TYPE"]), GroupID: groupID}
+			groups := playlist.Alternatives.groupMap(group.Type)
+			if groups == nil {
+				if err := d.report(newDecodeError(lineNumber, line, tagName, ErrInvalidMediaType), true); err != nil {
+					return fail(err)
 				}
-				playlist.Alternatives.ClosedCaptions[groupID] = append(playlist.Alternatives.ClosedCaptions[groupID], &Alternative{
-					Attributes: MediaAttrs(attrs),
-				})
-			default:
-				return nil, errors.New("invalid TYPE")
+				continue
+			}
+			if _, ok := groups[groupID]; !ok {
+				groups[groupID] = make([]*Alternative, 0)
+				playlist.Alternatives.GroupOrder = append(playlist.Alternatives.GroupOrder, group)
+			}
+			alt := &Alternative{
+				Attributes:     MediaAttrs(attrs),
+				AttributeOrder: attrOrder,
+				Comments:       comments,
+			}
+			groups[groupID] = append(groups[groupID], alt)
+			comments = nil
+			if opts.PreserveTagOrder {
+				playlist.TagOrder = append(playlist.TagOrder, tagName)
+				playlist.alternativeOrder = append(playlist.alternativeOrder, alt)
+			}
+		} else {
+			if err := d.checkTag(lineNumber, line, tagName); err != nil {
+				return fail(err)
 			}
-		} else if tagName != "" {
+			if err := d.checkDuplicate(lineNumber, line, tagName, playlist.Tags); err != nil {
+				return fail(err)
+			}
+			attachComments()
 			playlist.Tags.Add(&Tag{
 				Name:       tagName,
 				Attributes: AttributeString(line),
 			})
+			addTagOrder(tagName)
+		}
+	}
+	if streamInfAttrs != nil {
+		if err := d.report(newDecodeError(streamInfLine, streamInfRaw, TagExtXStreamInf, ErrInvalidStreamInf), true); err != nil {
+			playlist.TrailingComments = comments
+			return fail(err)
+		}
+		comments = append(streamComments, comments...)
+		if opts.PreserveTagOrder {
+			playlist.TagOrder = removeLastTag(playlist.TagOrder, TagExtXStreamInf)
 		}
 	}
+	playlist.TrailingComments = comments
+	playlist.Warnings = d.warnings
 	return &playlist, nil
 }
 
+// removeLastTag removes the last occurrence of the name from the tag order.
+func removeLastTag(order []string, name string) []string {
+	for i := len(order) - 1; i >= 0; i-- {
+		if order[i] == name {
+			return append(order[:i], order[i+1:]...)
+		}
+	}
+	return order
+}
+
 // Encode encodes a master playlist to io.Writer.
 func (playlist *MasterPlaylist) Encode(w io.Writer) error {
-	for _, tag := range playlist.Tags.List() {
-		err := tag.Encode(w)
-		if err != nil {
+	raw := playlist.Tags.withComments(playlist.Comments)
+	if playlist.TagOrder != nil {
+		if err := playlist.encodeInOrder(w, raw); err != nil {
 			return err
 		}
+		return encodeComments(w, playlist.TrailingComments)
 	}
-	for groupID, alternatives := range playlist.Alternatives.Video {
-		for _, alt := range alternatives {
-			if err := encodeExtXMedia(w, MediaTypeVideo, groupID, alt.Attributes); err != nil {
+	registry := tagRegistryOrDefault(playlist.registry)
+	list := raw.list(registry)
+	if playlist.positions != nil {
+		list = raw.listAnchored(registry, playlist.positions, isComment)
+	}
+	if err := encodeTagList(w, list); err != nil {
+		return err
+	}
+	for _, group := range playlist.Alternatives.Groups() {
+		for _, alt := range playlist.Alternatives.Renditions(group) {
+			if err := encodeExtXMedia(w, group.Type, group.GroupID, alt); err != nil {
 				return err
 			}
 		}
 	}
-	for groupID, alternatives := range playlist.Alternatives.Audio {
-		for _, alt := range alternatives {
-			if err := encodeExtXMedia(w, MediaTypeAudio, groupID, alt.Attributes); err != nil {
-				return err
-			}
+	for _, stream := range playlist.Streams {
+		if err := encodeStream(w, stream); err != nil {
+			return err
 		}
 	}
-	for groupID, alternatives := range playlist.Alternatives.Subtitles {
-		for _, alt := range alternatives {
-			if err := encodeExtXMedia(w, MediaTypeSubtitles, groupID, alt.Attributes); err != nil {
-				return err
-			}
+	for _, stream := range playlist.IFrameStreams {
+		if err := encodeIFrameStream(w, stream); err != nil {
+			return err
 		}
 	}
-	for groupID, alternatives := range playlist.Alternatives.ClosedCaptions {
-		for _, alt := range alternatives {
-			if err := encodeExtXMedia(w, MediaTypeClosedCaptions, groupID, alt.Attributes); err != nil {
-				return err
+	return encodeComments(w, playlist.TrailingComments)
+}
+
+// encodeInOrder writes the tags, the alternatives and the streams in the order of TagOrder.
+// The alternatives and the streams are written at the positions of EXT-X-MEDIA,
+// EXT-X-STREAM-INF and EXT-X-I-FRAME-STREAM-INF in TagOrder.
+// The alternatives are taken in the order in which they were decoded,
+// and then the others in the order of Alternatives#Groups.
+// The ones which exceed the positions follow the last one of the same kind.
+func (playlist *MasterPlaylist) encodeInOrder(w io.Writer, raw Tags) error {
+	type alternative struct {
+		group AlternativeGroup
+		alt   *Alternative
+	}
+	groups := make(map[*Alternative]AlternativeGroup)
+	for _, group := range playlist.Alternatives.Groups() {
+		for _, alt := range playlist.Alternatives.Renditions(group) {
+			groups[alt] = group
+		}
+	}
+	alternatives := make([]alternative, 0, len(groups))
+	taken := make(map[*Alternative]struct{}, len(groups))
+	for _, alt := range playlist.alternativeOrder {
+		group, ok := groups[alt]
+		if _, dup := taken[alt]; !ok || dup {
+			continue
+		}
+		taken[alt] = struct{}{}
+		alternatives = append(alternatives, alternative{group: group, alt: alt})
+	}
+	for _, group := range playlist.Alternatives.Groups() {
+		for _, alt := range playlist.Alternatives.Renditions(group) {
+			if _, ok := taken[alt]; !ok {
+				taken[alt] = struct{}{}
+				alternatives = append(alternatives, alternative{group: group, alt: alt})
 			}
 		}
 	}
-	for _, stream := range playlist.Streams {
-		if _, err := fmt.Fprintf(w, "#%s:%s\n", TagExtXStreamInf, Attributes(stream.Attributes).String()); err != nil {
-			return err
+	encodeEntry := func(name string, idx int) error {
+		switch name {
+		case TagExtXMedia:
+			return encodeExtXMedia(w, alternatives[idx].group.Type, alternatives[idx].group.GroupID, alternatives[idx].alt)
+		case TagExtXStreamInf:
+			return encodeStream(w, playlist.Streams[idx])
+		default:
+			return encodeIFrameStream(w, playlist.IFrameStreams[idx])
 		}
-		if _, err := fmt.Fprintln(w, stream.URI); err != nil {
-			return err
+	}
+	entries := map[string]int{
+		TagExtXMedia:           len(alternatives),
+		TagExtXStreamInf:       len(playlist.Streams),
+		TagExtXIFrameStreamInf: len(playlist.IFrameStreams),
+	}
+	positions := make(map[string]int, len(entries))
+	for _, name := range playlist.TagOrder {
+		if _, ok := entries[name]; ok {
+			positions[name]++
 		}
 	}
-	for _, stream := range playlist.IFrameStreams {
-		if _, err := fmt.Fprintf(w, "#%s:%s,URI=\"%s\"\n", TagExtXIFrameStreamInf, Attributes(stream.Attributes).String(), stream.URI); err != nil {
-			return err
+	tags := raw.Clone()
+	for name, n := range entries {
+		// The tags of the same names in Tags take the positions first.
+		slots := positions[name] - len(raw[name])
+		if slots <= 0 || slots > n {
+			slots = n
+		}
+		positions[name] = slots
+		tags[name] = append(tags[name], make([]string, slots)...)
+	}
+	used := make(map[string]int, len(entries))
+	for _, tag := range tags.listInOrder(tagRegistryOrDefault(playlist.registry), playlist.TagOrder) {
+		idx := used[tag.Name] - len(raw[tag.Name])
+		used[tag.Name]++
+		if _, ok := entries[tag.Name]; !ok || idx < 0 {
+			if err := encodeTagList(w, []*Tag{tag}); err != nil {
+				return err
+			}
+			continue
+		}
+		end := idx + 1
+		if end == positions[tag.Name] {
+			end = entries[tag.Name]
+		}
+		for ; idx < end; idx++ {
+			if err := encodeEntry(tag.Name, idx); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
-func encodeExtXMedia(w io.Writer, typ MediaType, groupID string, attrs MediaAttrs) error {
-	a := make(Attributes, len(attrs)-2)
-	for k, v := range attrs {
+func encodeStream(w io.Writer, stream *Stream) error {
+	if err := encodeComments(w, stream.Comments); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "#%s:%s\n", TagExtXStreamInf, encodeAttributes(Attributes(stream.Attributes), stream.AttributeOrder)); err != nil {
+		return err
+	}
+	if err := encodeComments(w, stream.URIComments); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, stream.URI)
+	return err
+}
+
+func encodeIFrameStream(w io.Writer, stream *Stream) error {
+	if err := encodeComments(w, stream.Comments); err != nil {
+		return err
+	}
+	if stream.AttributeOrder != nil {
+		attrs := make(Attributes, len(stream.Attributes)+1)
+		for k, v := range stream.Attributes {
+			attrs[k] = v
+		}
+		attrs["URI"] = `"` + stream.URI + `"`
+		_, err := fmt.Fprintf(w, "#%s:%s\n", TagExtXIFrameStreamInf, attrs.StringInOrder(stream.AttributeOrder))
+		return err
+	}
+	_, err := fmt.Fprintf(w, "#%s:%s,URI=\"%s\"\n", TagExtXIFrameStreamInf, Attributes(stream.Attributes).String(), stream.URI)
+	return err
+}
+
+func encodeExtXMedia(w io.Writer, typ MediaType, groupID string, alt *Alternative) error {
+	if err := encodeComments(w, alt.Comments); err != nil {
+		return err
+	}
+	if alt.AttributeOrder != nil {
+		a := make(Attributes, len(alt.Attributes))
+		for k, v := range alt.Attributes {
+			a[k] = v
+		}
+		a["TYPE"] = string(typ)
+		a["GROUP-ID"] = `"` + groupID + `"`
+		_, err := fmt.Fprintf(w, "#%s:%s\n", TagExtXMedia, a.StringInOrder(alt.AttributeOrder))
+		return err
+	}
+	a := make(Attributes, len(alt.Attributes))
+	for k, v := range alt.Attributes {
 		if k != "TYPE" && k != "GROUP-ID" {
 			a[k] = v
 		}
@@ -1,7 +1,7 @@
 package m3u8
 
 import (
-	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 )
@@ -16,34 +16,53 @@ const (
 	MediaTypeClosedCaptions MediaType = "CLOSED-CAPTIONS"
 )
 
+// SessionKeys returns the attributes list of the EXT-X-SESSION-KEY tags of a master playlist.
+// Invalid tags are skipped.
+func SessionKeys(tags Tags) []KeyAttrs {
+	return parseKeys(tags[TagExtXSessionKey])
+}
+
+// SetSessionKeys sets the attributes list of the EXT-X-SESSION-KEY tags of a master playlist.
+// If keys is empty, the tags are removed.
+func SetSessionKeys(tags Tags, keys []KeyAttrs) {
+	if len(keys) == 0 {
+		delete(tags, TagExtXSessionKey)
+		return
+	}
+	tags[TagExtXSessionKey] = encodeKeys(keys)
+}
+
 // StreamInfAttrs represents the attributes of the EXT-X-STREAM-INF tag.
 type StreamInfAttrs Attributes
 
+// StringInOrder encodes the attributes to a string in the order of the keys.
+// See Attributes#StringInOrder.
+func (attrs StreamInfAttrs) StringInOrder(order []string) string {
+	return Attributes(attrs).StringInOrder(order)
+}
+
 // Resolution returns the resolution of the stream.
 func (attrs StreamInfAttrs) Resolution() (width, height int, err error) {
 	return ParseResolution(attrs["RESOLUTION"])
 }
 
+// SetResolution sets the resolution of the stream.
+func (attrs StreamInfAttrs) SetResolution(resolution Resolution) {
+	attrs["RESOLUTION"] = resolution.String()
+}
+
 // ParseResolution parses the resolution string.
 func ParseResolution(resolution string) (width, height int, err error) {
-	idx := strings.Index(resolution, "x")
-	if idx == -1 || idx == 0 || idx == len(resolution)-1 {
-		return 0, 0, errors.New("invalid resolution")
-	}
-	width, err = strconv.Atoi(resolution[:idx])
+	r, err := AttributeValue(resolution).DecimalResolution()
 	if err != nil {
 		return 0, 0, err
 	}
-	height, err = strconv.Atoi(resolution[idx+1:])
-	if err != nil {
-		return 0, 0, err
-	}
-	return width, height, nil
+	return r.Width, r.Height, nil
 }
 
 // Bandwidth returns the bandwidth of the stream.
 func (attrs StreamInfAttrs) Bandwidth() (int64, error) {
-	return strconv.ParseInt(attrs["BANDWIDTH"], 10, 64)
+	return decimalInteger64(attrs["BANDWIDTH"])
 }
 
 // SetBandwidth sets the bandwidth of the stream.
@@ -53,7 +72,7 @@ func (attrs StreamInfAttrs) SetBandwidth(bandwidth int64) {
 
 // AverageBandwidth returns the average bandwidth of the stream.
 func (attrs StreamInfAttrs) AverageBandwidth() (int64, error) {
-	return strconv.ParseInt(attrs["AVERAGE-BANDWIDTH"], 10, 64)
+	return decimalInteger64(attrs["AVERAGE-BANDWIDTH"])
 }
 
 // SetAverageBandwidth sets the average bandwidth of the stream.
@@ -63,67 +82,73 @@ func (attrs StreamInfAttrs) SetAverageBandwidth(bandwidth int64) {
 
 // Codecs returns the codecs of the stream.
 func (attrs StreamInfAttrs) Codecs() []string {
-	return strings.Split(strings.Trim(attrs["CODECS"], `"`), ",")
+	return strings.Split(AttributeValue(attrs["CODECS"]).unquote(), ",")
 }
 
 // SetCodecs sets the codecs of the stream.
 func (attrs StreamInfAttrs) SetCodecs(codecs []string) {
-	attrs["CODECS"] = `"` + strings.Join(codecs, ",") + `"`
+	attrs["CODECS"] = string(EncodeQuotedString(strings.Join(codecs, ",")))
 }
 
 // FrameRate returns the frame rate of the stream.
 func (attrs StreamInfAttrs) FrameRate() (float64, error) {
-	return strconv.ParseFloat(attrs["FRAME-RATE"], 64)
+	return AttributeValue(attrs["FRAME-RATE"]).DecimalFloatingPoint()
 }
 
 // SetFrameRate sets the frame rate of the stream.
 func (attrs StreamInfAttrs) SetFrameRate(frameRate float64) {
-	attrs["FRAME-RATE"] = strconv.FormatFloat(frameRate, 'f', -1, 64)
+	attrs["FRAME-RATE"] = string(EncodeDecimalFloatingPoint(frameRate))
 }
 
 // Audio returns the audio group of the stream.
 func (attrs StreamInfAttrs) Audio() string {
-	return strings.Trim(attrs["AUDIO"], `"`)
+	return AttributeValue(attrs["AUDIO"]).unquote()
 }
 
 // SetAudio sets the audio group of the stream.
 func (attrs StreamInfAttrs) SetAudio(audio string) {
-	attrs["AUDIO"] = `"` + audio + `"`
+	attrs["AUDIO"] = string(EncodeQuotedString(audio))
 }
 
 // Video returns the video group of the stream.
 func (attrs StreamInfAttrs) Video() string {
-	return strings.Trim(attrs["VIDEO"], `"`)
+	return AttributeValue(attrs["VIDEO"]).unquote()
 }
 
 // SetVideo sets the video group of the stream.
 func (attrs StreamInfAttrs) SetVideo(video string) {
-	attrs["VIDEO"] = `"` + video + `"`
+	attrs["VIDEO"] = string(EncodeQuotedString(video))
 }
 
 // Subtitles returns the subtitles group of the stream.
 func (attrs StreamInfAttrs) Subtitles() string {
-	return strings.Trim(attrs["SUBTITLES"], `"`)
+	return AttributeValue(attrs["SUBTITLES"]).unquote()
 }
 
 // SetSubtitles sets the subtitles group of the stream.
 func (attrs StreamInfAttrs) SetSubtitles(subtitles string) {
-	attrs["SUBTITLES"] = `"` + subtitles + `"`
+	attrs["SUBTITLES"] = string(EncodeQuotedString(subtitles))
 }
 
 // ClosedCaptions returns the closed captions group of the stream.
 func (attrs StreamInfAttrs) ClosedCaptions() string {
-	return strings.Trim(attrs["CLOSED-CAPTIONS"], `"`)
+	return AttributeValue(attrs["CLOSED-CAPTIONS"]).unquote()
 }
 
 // SetClosedCaptions sets the closed captions group of the stream.
 func (attrs StreamInfAttrs) SetClosedCaptions(closedCaptions string) {
-	attrs["CLOSED-CAPTIONS"] = `"` + closedCaptions + `"`
+	attrs["CLOSED-CAPTIONS"] = string(EncodeQuotedString(closedCaptions))
 }
 
 // MediaAttrs represents the attributes of the EXT-X-MEDIA tag.
 type MediaAttrs Attributes
 
+// StringInOrder encodes the attributes to a string in the order of the keys.
+// See Attributes#StringInOrder.
+func (attrs MediaAttrs) StringInOrder(order []string) string {
+	return Attributes(attrs).StringInOrder(order)
+}
+
 // Type returns the type of the media.
 func (attrs MediaAttrs) Type() MediaType {
 	return MediaType(attrs["TYPE"])
@@ -137,53 +162,53 @@ func (attrs MediaAttrs) SetType(mediaType MediaType) {
 
 // URI returns the URI of the media.
 func (attrs MediaAttrs) URI() string {
-	return strings.Trim(attrs["URI"], `"`)
+	return AttributeValue(attrs["URI"]).unquote()
 }
 
 // SetURI sets the URI of the media.
 func (attrs MediaAttrs) SetURI(uri string) {
-	attrs["URI"] = `"` + uri + `"`
+	attrs["URI"] = string(EncodeQuotedString(uri))
 }
 
 // GroupID returns the group ID of the media.
 func (attrs MediaAttrs) GroupID() string {
-	return strings.Trim(attrs["GROUP-ID"], `"`)
+	return AttributeValue(attrs["GROUP-ID"]).unquote()
 }
 
 // SetGroupID sets the group ID of the media.
 // NOTICE: This value is ignored by MasterPlaylist#Encode.
 func (attrs MediaAttrs) SetGroupID(groupID string) {
-	attrs["GROUP-ID"] = `"` + groupID + `"`
+	attrs["GROUP-ID"] = string(EncodeQuotedString(groupID))
 }
 
 // Language returns the language of the media.
 func (attrs MediaAttrs) Language() string {
-	return strings.Trim(attrs["LANGUAGE"], `"`)
+	return AttributeValue(attrs["LANGUAGE"]).unquote()
 }
 
 // SetLanguage sets the language of the media.
 func (attrs MediaAttrs) SetLanguage(language string) {
-	attrs["LANGUAGE"] = `"` + language + `"`
+	attrs["LANGUAGE"] = string(EncodeQuotedString(language))
 }
 
 // AssocLanguage returns the associated language of the media.
 func (attrs MediaAttrs) AssocLanguage() string {
-	return strings.Trim(attrs["ASSOC-LANGUAGE"], `"`)
+	return AttributeValue(attrs["ASSOC-LANGUAGE"]).unquote()
 }
 
 // SetAssocLanguage sets the associated language of the media.
 func (attrs MediaAttrs) SetAssocLanguage(language string) {
-	attrs["ASSOC-LANGUAGE"] = `"` + language + `"`
+	attrs["ASSOC-LANGUAGE"] = string(EncodeQuotedString(language))
 }
 
 // Name returns the name of the media.
 func (attrs MediaAttrs) Name() string {
-	return strings.Trim(attrs["NAME"], `"`)
+	return AttributeValue(attrs["NAME"]).unquote()
 }
 
 // SetName sets the name of the media.
 func (attrs MediaAttrs) SetName(name string) {
-	attrs["NAME"] = `"` + name + `"`
+	attrs["NAME"] = string(EncodeQuotedString(name))
 }
 
 // Default returns the default flag of the media.
@@ -213,3 +238,57 @@ func (attrs MediaAttrs) SetAutoselect(autoselect bool) {
 		attrs["AUTOSELECT"] = "NO"
 	}
 }
+
+var streamInfAttrTypes = map[string]AttributeValueType{
+	"BANDWIDTH":           AttributeTypeDecimalInteger,
+	"AVERAGE-BANDWIDTH":   AttributeTypeDecimalInteger,
+	"SCORE":               AttributeTypeDecimalFloatingPoint,
+	"CODECS":              AttributeTypeQuotedString,
+	"SUPPLEMENTAL-CODECS": AttributeTypeQuotedString,
+	"RESOLUTION":          AttributeTypeDecimalResolution,
+	"FRAME-RATE":          AttributeTypeDecimalFloatingPoint,
+	"HDCP-LEVEL":          AttributeTypeEnumeratedString,
+	"ALLOWED-CPC":         AttributeTypeQuotedString,
+	"VIDEO-RANGE":         AttributeTypeEnumeratedString,
+	"REQ-VIDEO-LAYOUT":    AttributeTypeQuotedString,
+	"STABLE-VARIANT-ID":   AttributeTypeQuotedString,
+	"AUDIO":               AttributeTypeQuotedString,
+	"VIDEO":               AttributeTypeQuotedString,
+	"SUBTITLES":           AttributeTypeQuotedString,
+	"PATHWAY-ID":          AttributeTypeQuotedString,
+	"URI":                 AttributeTypeQuotedString,
+}
+
+// Validate checks the types of the known attributes.
+// CLOSED-CAPTIONS is either a quoted-string or NONE.
+func (attrs StreamInfAttrs) Validate() error {
+	if value, ok := attrs["CLOSED-CAPTIONS"]; ok && value != "NONE" {
+		if _, err := AttributeValue(value).QuotedString(); err != nil {
+			return fmt.Errorf("CLOSED-CAPTIONS: %w", err)
+		}
+	}
+	return validateAttributes(Attributes(attrs), streamInfAttrTypes)
+}
+
+var mediaAttrTypes = map[string]AttributeValueType{
+	"TYPE":                AttributeTypeEnumeratedString,
+	"URI":                 AttributeTypeQuotedString,
+	"GROUP-ID":            AttributeTypeQuotedString,
+	"LANGUAGE":            AttributeTypeQuotedString,
+	"ASSOC-LANGUAGE":      AttributeTypeQuotedString,
+	"NAME":                AttributeTypeQuotedString,
+	"STABLE-RENDITION-ID": AttributeTypeQuotedString,
+	"DEFAULT":             AttributeTypeEnumeratedString,
+	"AUTOSELECT":          AttributeTypeEnumeratedString,
+	"FORCED":              AttributeTypeEnumeratedString,
+	"INSTREAM-ID":         AttributeTypeQuotedString,
+	"BIT-DEPTH":           AttributeTypeDecimalInteger,
+	"SAMPLE-RATE":         AttributeTypeDecimalInteger,
+	"CHARACTERISTICS":     AttributeTypeQuotedString,
+	"CHANNELS":            AttributeTypeQuotedString,
+}
+
+// Validate checks the types of the known attributes.
+func (attrs MediaAttrs) Validate() error {
+	return validateAttributes(Attributes(attrs), mediaAttrTypes)
+}
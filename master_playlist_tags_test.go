@@ -35,4 +35,56 @@ func TestStreamInfAttrs(t *testing.T) {
 			require.Error(t, err)
 		})
 	})
+
+	t.Run("SetResolution", func(t *testing.T) {
+		streamInf := make(StreamInfAttrs)
+		streamInf.SetResolution(Resolution{Width: 1280, Height: 720})
+		assert.Equal(t, "1280x720", streamInf["RESOLUTION"])
+	})
+
+	t.Run("Bandwidth", func(t *testing.T) {
+		bandwidth, err := StreamInfAttrs{"BANDWIDTH": "1280000"}.Bandwidth()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1280000), bandwidth)
+		_, err = StreamInfAttrs{"BANDWIDTH": `"1280000"`}.Bandwidth()
+		assert.ErrorIs(t, err, ErrInvalidAttributeValue)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		streamInf := StreamInfAttrs{
+			"BANDWIDTH":       "1280000",
+			"CODECS":          `"avc1.4d401e,mp4a.40.2"`,
+			"RESOLUTION":      "1280x720",
+			"FRAME-RATE":      "29.97",
+			"CLOSED-CAPTIONS": "NONE",
+			"X-VENDOR":        "anything",
+		}
+		require.NoError(t, streamInf.Validate())
+		streamInf["CLOSED-CAPTIONS"] = "cc1"
+		assert.ErrorIs(t, streamInf.Validate(), ErrInvalidAttributeValue)
+		streamInf["CLOSED-CAPTIONS"] = `"cc1"`
+		streamInf["BANDWIDTH"] = `"1280000"`
+		err := streamInf.Validate()
+		assert.ErrorIs(t, err, ErrInvalidAttributeValue)
+		assert.EqualError(t, err, `BANDWIDTH: invalid decimal-integer: "\"1280000\""`)
+	})
+
+	t.Run("StringInOrder", func(t *testing.T) {
+		streamInf := StreamInfAttrs{"BANDWIDTH": "1280000", "AUDIO": `"aac"`, "CODECS": `"avc1.4d401e"`}
+		assert.Equal(t, `BANDWIDTH=1280000,AUDIO="aac",CODECS="avc1.4d401e"`, streamInf.StringInOrder([]string{"BANDWIDTH"}))
+	})
+}
+
+func TestMediaAttrs(t *testing.T) {
+	t.Run("Validate", func(t *testing.T) {
+		media := MediaAttrs{"TYPE": "AUDIO", "GROUP-ID": `"aac"`, "NAME": `"English"`, "DEFAULT": "YES"}
+		require.NoError(t, media.Validate())
+		media["NAME"] = "English"
+		assert.ErrorIs(t, media.Validate(), ErrInvalidAttributeValue)
+	})
+
+	t.Run("StringInOrder", func(t *testing.T) {
+		media := MediaAttrs{"TYPE": "AUDIO", "GROUP-ID": `"aac"`, "NAME": `"English"`}
+		assert.Equal(t, `TYPE=AUDIO,GROUP-ID="aac",NAME="English"`, media.StringInOrder([]string{"TYPE", "GROUP-ID"}))
+	})
 }
@@ -1,9 +1,14 @@
 package m3u8
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -90,6 +95,18 @@ audio-only.m3u8
 #EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=550000,URI="high-iframe.m3u8"
 `
 
+const sampleMultipleGroupsInput = `#EXTM3U
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",LANGUAGE="en",URI="subs/en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="stereo",NAME="English",LANGUAGE="en",URI="stereo/en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="surround",NAME="English",LANGUAGE="en",URI="surround/en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="stereo",NAME="Japanese",LANGUAGE="ja",URI="stereo/ja.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="Japanese",LANGUAGE="ja",URI="subs/ja.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="English",LANGUAGE="en",INSTREAM-ID="CC1"
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="angles",NAME="Main",URI="main.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO="stereo",SUBTITLES="subs",CLOSED-CAPTIONS="cc",VIDEO="angles"
+low.m3u8
+`
+
 func TestDecodeMasterPlaylist(t *testing.T) {
 	for idx, testData := range []struct {
 		input   string
@@ -112,3 +129,447 @@ func TestDecodeMasterPlaylist(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeMasterPlaylistErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input string
+		line  int
+		tag   string
+		err   error
+	}{
+		{
+			name:  "invalid_attributes",
+			input: "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1280000,CODECS=\"avc1\n",
+			line:  2,
+			tag:   TagExtXStreamInf,
+			err:   ErrInvalidAttributes,
+		},
+		{
+			name:  "tag_after_stream_inf",
+			input: "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1280000\n\n#EXT-X-INDEPENDENT-SEGMENTS\nlow.m3u8\n",
+			line:  2,
+			tag:   TagExtXStreamInf,
+			err:   ErrInvalidStreamInf,
+		},
+		{
+			name:  "missing_group_id",
+			input: "#EXTM3U\n#EXT-X-MEDIA:TYPE=AUDIO,NAME=\"English\"\n",
+			line:  2,
+			tag:   TagExtXMedia,
+			err:   ErrMissingGroupID,
+		},
+		{
+			name:  "invalid_type",
+			input: "#EXTM3U\n#EXT-X-MEDIA:TYPE=FOO,GROUP-ID=\"aac\"\n",
+			line:  2,
+			tag:   TagExtXMedia,
+			err:   ErrInvalidMediaType,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := DecodeMasterPlaylist(bytes.NewReader([]byte(tc.input)))
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tc.err)
+			var decodeErr *DecodeError
+			require.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, tc.line, decodeErr.Line)
+			assert.Equal(t, tc.tag, decodeErr.Tag)
+		})
+	}
+}
+
+func TestDecodeMasterPlaylistPartialResult(t *testing.T) {
+	const header = "#EXTM3U\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1280000\n" +
+		"low.m3u8\n"
+
+	t.Run("missing_stream_uri", func(t *testing.T) {
+		input := header + "#EXT-X-STREAM-INF:BANDWIDTH=2560000\n"
+		playlist, err := DecodeMasterPlaylist(bytes.NewReader([]byte(input)))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidStreamInf)
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, 4, decodeErr.Line)
+		assert.Equal(t, TagExtXStreamInf, decodeErr.Tag)
+		assert.Equal(t, "#EXT-X-STREAM-INF:BANDWIDTH=2560000", decodeErr.Raw)
+		require.NotNil(t, playlist)
+		require.Len(t, playlist.Streams, 1)
+		assert.Equal(t, "low.m3u8", playlist.Streams[0].URI)
+	})
+
+	t.Run("missing_stream_uri_lenient", func(t *testing.T) {
+		input := header + "#EXT-X-STREAM-INF:BANDWIDTH=2560000\n"
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{Mode: DecodeModeLenient})
+		require.NoError(t, err)
+		require.Len(t, playlist.Warnings, 1)
+		assert.ErrorIs(t, playlist.Warnings[0], ErrInvalidStreamInf)
+		assert.Len(t, playlist.Streams, 1)
+	})
+
+	t.Run("tag_after_stream_inf", func(t *testing.T) {
+		input := header + "#EXT-X-STREAM-INF:BANDWIDTH=2560000\n#EXT-X-INDEPENDENT-SEGMENTS\nmid.m3u8\n"
+		playlist, err := DecodeMasterPlaylist(bytes.NewReader([]byte(input)))
+		assert.ErrorIs(t, err, ErrInvalidStreamInf)
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, 4, decodeErr.Line)
+		assert.Equal(t, TagExtXStreamInf, decodeErr.Tag)
+		assert.Equal(t, "#EXT-X-STREAM-INF:BANDWIDTH=2560000", decodeErr.Raw)
+		require.NotNil(t, playlist)
+		require.Len(t, playlist.Streams, 1)
+		assert.Equal(t, "low.m3u8", playlist.Streams[0].URI)
+	})
+
+	t.Run("tag_after_stream_inf_lenient", func(t *testing.T) {
+		input := header +
+			"# mid\n" +
+			"#EXT-X-STREAM-INF:BANDWIDTH=2560000,RESOLUTION=1280x720\n" +
+			"#EXT-X-INDEPENDENT-SEGMENTS\n" +
+			"#EXT-X-START:TIME-OFFSET=0\n" +
+			"#EXT-X-STREAM-INF:BANDWIDTH=7680000\n" +
+			"high.m3u8\n"
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{
+			Mode:             DecodeModeLenient,
+			PreserveTagOrder: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, playlist.Warnings, 1)
+		assert.ErrorIs(t, playlist.Warnings[0], ErrInvalidStreamInf)
+		var decodeErr *DecodeError
+		require.ErrorAs(t, playlist.Warnings[0], &decodeErr)
+		assert.Equal(t, 5, decodeErr.Line)
+		require.Len(t, playlist.Streams, 2)
+		assert.Equal(t, "high.m3u8", playlist.Streams[1].URI)
+		assert.Equal(t, StreamInfAttrs{"BANDWIDTH": "7680000"}, playlist.Streams[1].Attributes)
+		assert.Nil(t, playlist.Streams[1].Comments)
+		assert.Equal(t, []string{" mid"}, playlist.Comments)
+		assert.Equal(t, []string{TagExtM3U, TagExtXStreamInf, CommentMarker, TagExtXIndependentSegments, TagExtXStart, TagExtXStreamInf}, playlist.TagOrder)
+	})
+
+	t.Run("missing_group_id", func(t *testing.T) {
+		input := header + "#EXT-X-MEDIA:TYPE=AUDIO,NAME=\"English\"\n"
+		playlist, err := DecodeMasterPlaylist(bytes.NewReader([]byte(input)))
+		assert.ErrorIs(t, err, ErrMissingGroupID)
+		require.NotNil(t, playlist)
+		require.Len(t, playlist.Streams, 1)
+		assert.Equal(t, "low.m3u8", playlist.Streams[0].URI)
+	})
+
+	t.Run("invalid_media_type", func(t *testing.T) {
+		input := header + "#EXT-X-MEDIA:TYPE=FOO,GROUP-ID=\"aac\",NAME=\"English\"\n"
+		playlist, err := DecodeMasterPlaylist(bytes.NewReader([]byte(input)))
+		assert.ErrorIs(t, err, ErrInvalidMediaType)
+		require.NotNil(t, playlist)
+		assert.Len(t, playlist.Streams, 1)
+	})
+
+	t.Run("max_playlist_tags", func(t *testing.T) {
+		input := header + "#EXT-X-INDEPENDENT-SEGMENTS\n"
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{MaxPlaylistTags: 2})
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		require.NotNil(t, playlist)
+		assert.Len(t, playlist.Streams, 1)
+	})
+
+	t.Run("too_long_line", func(t *testing.T) {
+		input := header + "#EXT-X-SESSION-DATA:DATA-ID=\"com.example\",VALUE=\"" + strings.Repeat("a", bufio.MaxScanTokenSize) + "\"\n"
+		playlist, err := DecodeMasterPlaylist(bytes.NewReader([]byte(input)))
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		require.NotNil(t, playlist)
+		assert.Len(t, playlist.Streams, 1)
+	})
+
+	t.Run("read_error", func(t *testing.T) {
+		readErr := errors.New("connection reset")
+		r := io.MultiReader(strings.NewReader(header), iotest.ErrReader(readErr))
+		playlist, err := DecodeMasterPlaylist(r)
+		assert.ErrorIs(t, err, readErr)
+		require.NotNil(t, playlist)
+		assert.Len(t, playlist.Streams, 1)
+	})
+}
+
+func TestDecodeMasterPlaylistWithOptions(t *testing.T) {
+	t.Run("preserve_tag_order", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-SESSION-DATA:DATA-ID=\"com.example.title\",VALUE=\"title\"\n" +
+			"#EXT-X-INDEPENDENT-SEGMENTS\n" +
+			"#EXT-X-VERSION:6\n" +
+			"#EXT-X-STREAM-INF:BANDWIDTH=1280000\n" +
+			"low.m3u8\n"
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{PreserveTagOrder: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"EXTM3U", "EXT-X-SESSION-DATA", "EXT-X-INDEPENDENT-SEGMENTS", "EXT-X-VERSION", "EXT-X-STREAM-INF"}, playlist.TagOrder)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+	})
+
+	t.Run("preserve_stream_order", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO=\"aac\"\n" +
+			"low.m3u8\n" +
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"English\",URI=\"en.m3u8\"\n" +
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"ac3\",NAME=\"English\",URI=\"en-ac3.m3u8\"\n" +
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"Japanese\",URI=\"ja.m3u8\"\n" +
+			"#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI=\"low/iframe.m3u8\"\n" +
+			"#EXT-X-STREAM-INF:BANDWIDTH=2560000,AUDIO=\"aac\"\n" +
+			"mid.m3u8\n" +
+			"#EXT-X-SESSION-DATA:DATA-ID=\"com.example.title\",VALUE=\"title\"\n"
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{PreserveTagOrder: true, PreserveAttributeOrder: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"EXTM3U", "EXT-X-STREAM-INF", "EXT-X-MEDIA", "EXT-X-MEDIA", "EXT-X-MEDIA", "EXT-X-I-FRAME-STREAM-INF", "EXT-X-STREAM-INF", "EXT-X-SESSION-DATA"}, playlist.TagOrder)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+
+		playlist.Streams = append(playlist.Streams, &Stream{
+			Attributes: StreamInfAttrs{"BANDWIDTH": "7680000"},
+			URI:        "high.m3u8",
+		})
+		w = bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, "#EXTM3U\n"+
+			"#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO=\"aac\"\n"+
+			"low.m3u8\n"+
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"English\",URI=\"en.m3u8\"\n"+
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"ac3\",NAME=\"English\",URI=\"en-ac3.m3u8\"\n"+
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"Japanese\",URI=\"ja.m3u8\"\n"+
+			"#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI=\"low/iframe.m3u8\"\n"+
+			"#EXT-X-STREAM-INF:BANDWIDTH=2560000,AUDIO=\"aac\"\n"+
+			"mid.m3u8\n"+
+			"#EXT-X-STREAM-INF:BANDWIDTH=7680000\n"+
+			"high.m3u8\n"+
+			"#EXT-X-SESSION-DATA:DATA-ID=\"com.example.title\",VALUE=\"title\"\n", w.String())
+
+		playlist, err = DecodeMasterPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		w = bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, "#EXTM3U\n"+
+			"#EXT-X-SESSION-DATA:DATA-ID=\"com.example.title\",VALUE=\"title\"\n"+
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"English\",URI=\"en.m3u8\"\n"+
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"Japanese\",URI=\"ja.m3u8\"\n"+
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"ac3\",NAME=\"English\",URI=\"en-ac3.m3u8\"\n"+
+			"#EXT-X-STREAM-INF:AUDIO=\"aac\",BANDWIDTH=1280000\n"+
+			"low.m3u8\n"+
+			"#EXT-X-STREAM-INF:AUDIO=\"aac\",BANDWIDTH=2560000\n"+
+			"mid.m3u8\n"+
+			"#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI=\"low/iframe.m3u8\"\n", w.String())
+	})
+
+	t.Run("preserve_attribute_order", func(t *testing.T) {
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(sampleAlternativeStreamInput)), &DecodeOptions{PreserveAttributeOrder: true})
+		require.NoError(t, err)
+		require.Len(t, playlist.Streams, 3)
+		assert.Equal(t, []string{"BANDWIDTH", "CODECS", "AUDIO"}, playlist.Streams[0].AttributeOrder)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, sampleAlternativeStreamInput, w.String())
+
+		playlist, err = DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(sampleIFrameOnlyInput)), &DecodeOptions{PreserveAttributeOrder: true})
+		require.NoError(t, err)
+		w = bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=1280000
+low-audio-video.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2560000
+middle-audio-video.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=7680000
+high-audio-video.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=65000,CODECS="mp4a.40.5"
+audio-only.m3u8
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI="low-iframe.m3u8"
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=150000,URI="middle-iframe.m3u8"
+#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=550000,URI="high-iframe.m3u8"
+`, w.String())
+	})
+
+	t.Run("canonical_attribute_order", func(t *testing.T) {
+		playlist, err := DecodeMasterPlaylist(bytes.NewReader([]byte(sampleMaster02Input)))
+		require.NoError(t, err)
+		for _, stream := range playlist.Streams {
+			stream.AttributeOrder = []string{"BANDWIDTH", "AVERAGE-BANDWIDTH"}
+		}
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,AVERAGE-BANDWIDTH=1000000
+http://example.com/low.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=2560000,AVERAGE-BANDWIDTH=2000000
+http://example.com/mid.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=7680000,AVERAGE-BANDWIDTH=6000000
+http://example.com/hi.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=65000,CODECS="mp4a.40.5"
+http://example.com/audio-only.m3u8
+`, w.String())
+	})
+}
+
+func TestAlternatives(t *testing.T) {
+	t.Run("decoded_order", func(t *testing.T) {
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(sampleMultipleGroupsInput)), &DecodeOptions{PreserveAttributeOrder: true})
+		require.NoError(t, err)
+		assert.Equal(t, []AlternativeGroup{
+			{Type: MediaTypeSubtitles, GroupID: "subs"},
+			{Type: MediaTypeAudio, GroupID: "stereo"},
+			{Type: MediaTypeAudio, GroupID: "surround"},
+			{Type: MediaTypeClosedCaptions, GroupID: "cc"},
+			{Type: MediaTypeVideo, GroupID: "angles"},
+		}, playlist.Alternatives.Groups())
+		for i := 0; i < 10; i++ {
+			w := bytes.NewBuffer(nil)
+			require.NoError(t, playlist.Encode(w))
+			assert.Equal(t, `#EXTM3U
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="English",LANGUAGE="en",URI="subs/en.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",NAME="Japanese",LANGUAGE="ja",URI="subs/ja.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="stereo",NAME="English",LANGUAGE="en",URI="stereo/en.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="stereo",NAME="Japanese",LANGUAGE="ja",URI="stereo/ja.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="surround",NAME="English",LANGUAGE="en",URI="surround/en.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="English",LANGUAGE="en",INSTREAM-ID="CC1"
+#EXT-X-MEDIA:TYPE=VIDEO,GROUP-ID="angles",NAME="Main",URI="main.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO="stereo",SUBTITLES="subs",CLOSED-CAPTIONS="cc",VIDEO="angles"
+low.m3u8
+`, w.String())
+		}
+	})
+
+	t.Run("unlisted_groups", func(t *testing.T) {
+		alts := Alternatives{
+			Audio: map[string][]*Alternative{
+				"b": {{Attributes: MediaAttrs{"NAME": `"b"`}}},
+				"a": {{Attributes: MediaAttrs{"NAME": `"a"`}}},
+				"c": {{Attributes: MediaAttrs{"NAME": `"c"`}}},
+			},
+			Video: map[string][]*Alternative{
+				"v": {{Attributes: MediaAttrs{"NAME": `"v"`}}},
+			},
+			GroupOrder: []AlternativeGroup{
+				{Type: MediaTypeAudio, GroupID: "c"},
+				{Type: MediaTypeAudio, GroupID: "missing"},
+				{Type: MediaTypeAudio, GroupID: "c"},
+			},
+		}
+		assert.Equal(t, []AlternativeGroup{
+			{Type: MediaTypeAudio, GroupID: "c"},
+			{Type: MediaTypeVideo, GroupID: "v"},
+			{Type: MediaTypeAudio, GroupID: "a"},
+			{Type: MediaTypeAudio, GroupID: "b"},
+		}, alts.Groups())
+		assert.Equal(t, `"v"`, alts.Renditions(AlternativeGroup{Type: MediaTypeVideo, GroupID: "v"})[0].Attributes["NAME"])
+		assert.Nil(t, alts.Renditions(AlternativeGroup{Type: "UNKNOWN", GroupID: "v"}))
+	})
+
+	t.Run("sort", func(t *testing.T) {
+		playlist, err := DecodeMasterPlaylist(bytes.NewReader([]byte(sampleMultipleGroupsInput)))
+		require.NoError(t, err)
+		playlist.Alternatives.SortGroups(func(a, b AlternativeGroup) bool {
+			return a.GroupID < b.GroupID
+		})
+		playlist.Alternatives.SortRenditions(func(a, b *Alternative) bool {
+			return a.Attributes.Language() > b.Attributes.Language()
+		})
+		assert.Equal(t, []AlternativeGroup{
+			{Type: MediaTypeVideo, GroupID: "angles"},
+			{Type: MediaTypeClosedCaptions, GroupID: "cc"},
+			{Type: MediaTypeAudio, GroupID: "stereo"},
+			{Type: MediaTypeSubtitles, GroupID: "subs"},
+			{Type: MediaTypeAudio, GroupID: "surround"},
+		}, playlist.Alternatives.Groups())
+		stereo := playlist.Alternatives.Renditions(AlternativeGroup{Type: MediaTypeAudio, GroupID: "stereo"})
+		require.Len(t, stereo, 2)
+		assert.Equal(t, "ja", stereo[0].Attributes.Language())
+		assert.Equal(t, "en", stereo[1].Attributes.Language())
+	})
+}
+
+func TestDecodeMasterPlaylistComments(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"# generated by packager X\n" +
+		"#EXT-X-INDEPENDENT-SEGMENTS\n" +
+		"# audio\n" +
+		"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"English\",URI=\"en.m3u8\"\n" +
+		"# low\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO=\"aac\"\n" +
+		"# uri\n" +
+		"low.m3u8\n" +
+		"# i-frame\n" +
+		"#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI=\"low/iframe.m3u8\"\n"
+
+	t.Run("preserve_tag_order", func(t *testing.T) {
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{
+			PreserveTagOrder:       true,
+			PreserveAttributeOrder: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{" generated by packager X"}, playlist.Comments)
+		assert.Equal(t, []string{" audio"}, playlist.Alternatives.Audio["aac"][0].Comments)
+		require.Len(t, playlist.Streams, 1)
+		assert.Equal(t, []string{" low"}, playlist.Streams[0].Comments)
+		assert.Equal(t, []string{" uri"}, playlist.Streams[0].URIComments)
+		require.Len(t, playlist.IFrameStreams, 1)
+		assert.Equal(t, []string{" i-frame"}, playlist.IFrameStreams[0].Comments)
+
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+	})
+
+	t.Run("default_order", func(t *testing.T) {
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{PreserveAttributeOrder: true})
+		require.NoError(t, err)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+
+		playlist.Tags.Add(&Tag{Name: TagExtXVersion, Attributes: "6"})
+		w = bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, "#EXTM3U\n"+
+			"#EXT-X-VERSION:6\n"+
+			"# generated by packager X\n"+
+			"#EXT-X-INDEPENDENT-SEGMENTS\n"+
+			"# audio\n"+
+			"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"aac\",NAME=\"English\",URI=\"en.m3u8\"\n"+
+			"# low\n"+
+			"#EXT-X-STREAM-INF:BANDWIDTH=1280000,AUDIO=\"aac\"\n"+
+			"# uri\n"+
+			"low.m3u8\n"+
+			"# i-frame\n"+
+			"#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=86000,URI=\"low/iframe.m3u8\"\n", w.String())
+	})
+}
+
+func TestMasterPlaylistSessionKeys(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI=\"skd://key1\",KEYFORMAT=\"com.apple.streamingkeydelivery\",KEYFORMATVERSIONS=\"1\"\n" +
+		"#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI=\"data:text/plain;base64,AAAA\",KEYFORMAT=\"urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed\"\n" +
+		"#EXT-X-STREAM-INF:BANDWIDTH=1280000\n" +
+		"low.m3u8\n"
+	playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{Mode: DecodeModeStrict})
+	require.NoError(t, err)
+	keys := SessionKeys(playlist.Tags)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "skd://key1", keys[0].URI())
+	assert.Equal(t, "urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed", keys[1].KeyFormat())
+
+	keys[0].SetIV([16]byte{15: 1})
+	SetSessionKeys(playlist.Tags, keys[:1])
+	w := bytes.NewBuffer(nil)
+	require.NoError(t, playlist.Encode(w))
+	assert.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-SESSION-KEY:IV=0x00000000000000000000000000000001,KEYFORMAT=\"com.apple.streamingkeydelivery\",KEYFORMATVERSIONS=\"1\",METHOD=SAMPLE-AES,URI=\"skd://key1\"\n"+
+		"#EXT-X-STREAM-INF:BANDWIDTH=1280000\n"+
+		"low.m3u8\n", w.String())
+
+	SetSessionKeys(playlist.Tags, nil)
+	assert.NotContains(t, playlist.Tags, TagExtXSessionKey)
+
+	_, err = DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte("#EXTM3U\n#EXT-X-SESSION-KEY:METHOD=AES-128,URI=key1\n")), &DecodeOptions{Mode: DecodeModeStrict})
+	assert.ErrorIs(t, err, ErrInvalidAttributeValue)
+	_, err = DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte("#EXTM3U\n#EXT-X-SESSION-KEY:URI=\"key1\"\n")), &DecodeOptions{Mode: DecodeModeStrict})
+	assert.ErrorIs(t, err, ErrMissingMethod)
+}
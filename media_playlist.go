@@ -1,14 +1,23 @@
 package m3u8
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 )
 
-// ErrUnexpectedSegmentTags is returned when segment tags are found without a segment URI.
-var ErrUnexpectedSegmentTags = errors.New("unexpected segment tags")
+var (
+	// ErrUnexpectedSegmentTags is returned when segment tags are found without a segment URI.
+	ErrUnexpectedSegmentTags = errors.New("unexpected segment tags")
+
+	// ErrNoSegments is returned when a media playlist has neither segments nor partial segments.
+	ErrNoSegments = errors.New("no segments")
+)
+
+// trailingTagMarker marks the position of a tag in TrailingTags of a media playlist.
+// It never matches a tag name because a tag name excludes the leading "#".
+const trailingTagMarker = "#TAG"
 
 // MediaPlaylist represents a media playlist.
 type MediaPlaylist struct {
@@ -16,12 +25,57 @@ type MediaPlaylist struct {
 	// This list does not include segment tags.
 	Tags MediaPlaylistTags
 
+	// TagOrder is the order of the names of Tags. See DecodeOptions.PreserveTagOrder.
+	TagOrder []string
+
+	// Comments is a list of comments among the tags of the media playlist. See DecodeOptions.PreserveTagOrder.
+	Comments []string
+
+	// TrailingComments is a list of comments after the last segment.
+	// Encode writes them among PreloadHints, EXT-X-ENDLIST and RenditionReports
+	// where they were decoded, or at the end of the media playlist.
+	TrailingComments []string
+
+	// TrailingTags is a list of unregistered tags after the last segment, such as vendor cue markers.
+	// Encode writes them among PreloadHints, EXT-X-ENDLIST and RenditionReports
+	// where they were decoded, or right after the last segment.
+	TrailingTags []*Tag
+
 	// Segments is a list of segments in the media playlist.
 	Segments []*Segment
 
+	// PartialSegment is the segment which is still in progress at the end of
+	// the media playlist. It has no URI and consists of the partial segments
+	// published so far.
+	// If the media playlist does not end with partial segments, it is nil.
+	PartialSegment *Segment
+
+	// PreloadHints is a list of EXT-X-PRELOAD-HINT tags in the media playlist.
+	PreloadHints []*PreloadHint
+
+	// RenditionReports is a list of EXT-X-RENDITION-REPORT tags in the media playlist.
+	// They are always encoded after the last segment.
+	RenditionReports []*RenditionReport
+
 	// EndList indicates that no more media segments will be added to the
 	// media playlist file in the future.
 	EndList bool
+
+	// Warnings is a list of problems found by DecodeMediaPlaylistWithOptions in DecodeModeLenient.
+	Warnings []error
+
+	// positions is the order of the names of Tags in which they were decoded.
+	// When TagOrder is nil, Encode uses it to write Comments where they were.
+	positions []string
+
+	// trailer is the order in which the EXT-X-PRELOAD-HINT, EXT-X-ENDLIST and
+	// EXT-X-RENDITION-REPORT tags, TrailingTags and TrailingComments were decoded.
+	// CommentMarker marks the position of a comment, and trailingTagMarker that of a tag in TrailingTags.
+	trailer []string
+
+	// registry is the TagRegistry used to decode the media playlist.
+	// Encode sorts the tags by the orders in it.
+	registry *TagRegistry
 }
 
 // Segment represents a media segment with its tags.
@@ -32,6 +86,15 @@ type Segment struct {
 	// URI is the URI of the segment.
 	URI string
 
+	// Parts is a list of partial segments which make up the segment.
+	Parts []*Part
+
+	// TagOrder is the order of the names of Tags and EXT-X-PART tags. See DecodeOptions.PreserveTagOrder.
+	TagOrder []string
+
+	// Comments is a list of comments which precede the URI of the segment. See DecodeOptions.PreserveTagOrder.
+	Comments []string
+
 	// Sequence is the media sequence number of the segment.
 	// This field is set by DecodeMediaPlaylist.
 	// When encoding a media playlist, this field is ignored.
@@ -39,49 +102,203 @@ type Segment struct {
 
 	// DiscontinuitySequence is the discontinuity sequence number of the segment.
 	// This field is set by DecodeMediaPlaylist.
+	// In a delta update, discontinuities in the skipped segments are not counted.
 	// When encoding a media playlist, this field is ignored.
 	DiscontinuitySequence int64
+
+	// positions is the order of the names of the tags in which they were decoded.
+	// When TagOrder is nil, Encode uses it to write EXT-X-PART tags and Comments where they were,
+	// and Resolve uses it to find the EXT-X-KEY tags which apply to EXT-X-MAP.
+	positions []string
+}
+
+// Part represents a partial segment.
+type Part struct {
+	// Attributes is a list of attributes in the EXT-X-PART tag.
+	Attributes PartAttrs
+
+	// AttributeOrder is the order of the keys of Attributes. See DecodeOptions.PreserveAttributeOrder.
+	AttributeOrder []string
+}
+
+// PreloadHint represents a hint of a resource which will be published soon.
+type PreloadHint struct {
+	// Attributes is a list of attributes in the EXT-X-PRELOAD-HINT tag.
+	Attributes PreloadHintAttrs
+
+	// AttributeOrder is the order of the keys of Attributes. See DecodeOptions.PreserveAttributeOrder.
+	AttributeOrder []string
+}
+
+// RenditionReport represents a report of the latest state of another rendition.
+type RenditionReport struct {
+	// Attributes is a list of attributes in the EXT-X-RENDITION-REPORT tag.
+	Attributes RenditionReportAttrs
+
+	// AttributeOrder is the order of the keys of Attributes. See DecodeOptions.PreserveAttributeOrder.
+	AttributeOrder []string
+}
+
+// NewRenditionReport creates a rendition report which describes the last
+// segment and the last partial segment of the media playlist.
+// It returns ErrNoSegments if the media playlist has neither segments nor partial segments,
+// because LAST-MSN is required.
+func NewRenditionReport(uri string, playlist *MediaPlaylist) (*RenditionReport, error) {
+	attrs := make(RenditionReportAttrs)
+	attrs.SetURI(uri)
+	sequence := playlist.firstSequence() + int64(len(playlist.Segments))
+	if playlist.PartialSegment != nil && len(playlist.PartialSegment.Parts) != 0 {
+		attrs.SetLastMSN(sequence)
+		attrs.SetLastPart(int64(len(playlist.PartialSegment.Parts) - 1))
+	} else if len(playlist.Segments) != 0 {
+		attrs.SetLastMSN(sequence - 1)
+		if parts := playlist.Segments[len(playlist.Segments)-1].Parts; len(parts) != 0 {
+			attrs.SetLastPart(int64(len(parts) - 1))
+		}
+	} else {
+		return nil, ErrNoSegments
+	}
+	return &RenditionReport{Attributes: attrs}, nil
+}
+
+// NewRenditionReports creates rendition reports from the map of URIs to sibling media playlists.
+// The reports are sorted by URI.
+// The media playlists which have neither segments nor partial segments are skipped.
+func NewRenditionReports(playlists map[string]*MediaPlaylist) []*RenditionReport {
+	uris := make([]string, 0, len(playlists))
+	for uri := range playlists {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	reports := make([]*RenditionReport, 0, len(uris))
+	for _, uri := range uris {
+		if report, err := NewRenditionReport(uri, playlists[uri]); err == nil {
+			reports = append(reports, report)
+		}
+	}
+	return reports
 }
 
 // DecodeMediaPlaylist decodes a media playlist from io.Reader.
+// If it fails, it returns the error with the media playlist decoded so far,
+// which does not include the segment of the line where the error occurred.
 func DecodeMediaPlaylist(r io.Reader) (*MediaPlaylist, error) {
-	scanner := bufio.NewScanner(r)
-	var playlist MediaPlaylist
-	playlist.Tags = make(MediaPlaylistTags)
-	playlist.Segments = make([]*Segment, 0, 8)
-	segmentTags := make(SegmentTags)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
-		tagName := TagName(line)
-		if tagName == "" {
-			playlist.Segments = append(playlist.Segments, &Segment{
-				Tags: segmentTags,
-				URI:  line,
-			})
-			segmentTags = make(SegmentTags)
-		} else if IsSegmentTagName(tagName) {
-			segmentTags.Raw().Add(&Tag{
-				Name:       tagName,
-				Attributes: AttributeString(line),
-			})
-		} else if tagName == TagExtXEndlist {
-			playlist.EndList = true
-		} else {
-			playlist.Tags.Raw().Add(&Tag{
-				Name:       tagName,
-				Attributes: AttributeString(line),
-			})
+	return DecodeMediaPlaylistWithOptions(r, nil)
+}
+
+// DecodeMediaPlaylistWithOptions decodes a media playlist from io.Reader with the options.
+func DecodeMediaPlaylistWithOptions(r io.Reader, opts *DecodeOptions) (*MediaPlaylist, error) {
+	reader, err := newMediaPlaylistReader(r, opts)
+	segments := make([]*Segment, 0, 8)
+	for err == nil {
+		var segment *Segment
+		segment, err = reader.Next()
+		if err == nil {
+			segments = append(segments, segment)
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	playlist := reader.Playlist()
+	playlist.Segments = segments
+	playlist.setSequences()
+	if err == io.EOF {
+		return playlist, nil
 	}
+	return playlist, err
+}
+
+// Encode encodes a media playlist to io.Writer.
+func (playlist *MediaPlaylist) Encode(w io.Writer) error {
+	pw := NewMediaPlaylistWriterWithRegistry(w, playlist.registry)
+	if err := pw.writeHeader(playlist.Tags, playlist.TagOrder, playlist.positions, playlist.Comments); err != nil {
+		return err
+	}
+	for _, segment := range playlist.allSegments() {
+		if err := pw.WriteSegment(segment); err != nil {
+			return err
+		}
+	}
+	return playlist.encodeTrailer(pw)
+}
+
+// encodeTrailer writes TrailingTags, PreloadHints, EXT-X-ENDLIST, RenditionReports and TrailingComments
+// in the order in which they were decoded.
+// The tags which exceed the decoded positions follow the last one of the same kind,
+// and the others are written at the end in this order.
+func (playlist *MediaPlaylist) encodeTrailer(pw *MediaPlaylistWriter) error {
+	kinds := []string{trailingTagMarker, TagExtXPreloadHint, TagExtXEndlist, TagExtXRenditionReport, CommentMarker}
+	entries := map[string]int{
+		trailingTagMarker:      len(playlist.TrailingTags),
+		TagExtXPreloadHint:     len(playlist.PreloadHints),
+		TagExtXRenditionReport: len(playlist.RenditionReports),
+		CommentMarker:          len(playlist.TrailingComments),
+	}
+	if playlist.EndList {
+		entries[TagExtXEndlist] = 1
+	}
+	encodeEntry := func(name string, idx int) error {
+		switch name {
+		case trailingTagMarker:
+			return pw.WriteTag(playlist.TrailingTags[idx])
+		case TagExtXPreloadHint:
+			return pw.WritePreloadHint(playlist.PreloadHints[idx])
+		case TagExtXEndlist:
+			return pw.WriteEndList()
+		case TagExtXRenditionReport:
+			return pw.WriteRenditionReport(playlist.RenditionReports[idx])
+		default:
+			return pw.WriteComment(playlist.TrailingComments[idx])
+		}
+	}
+	used := make(map[string]int, len(entries))
+	encodeUntil := func(name string, end int) error {
+		if end > entries[name] {
+			end = entries[name]
+		}
+		for ; used[name] < end; used[name]++ {
+			if err := encodeEntry(name, used[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	last := make(map[string]int, len(entries))
+	for i, name := range playlist.trailer {
+		last[name] = i
+	}
+	for i, name := range playlist.trailer {
+		end := used[name] + 1
+		if last[name] == i && name != CommentMarker {
+			end = entries[name]
+		}
+		if err := encodeUntil(name, end); err != nil {
+			return err
+		}
+	}
+	for _, name := range kinds {
+		if err := encodeUntil(name, entries[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// firstSequence returns the media sequence number of the first segment in Segments.
+// In a delta update, it takes the segments skipped by EXT-X-SKIP into account.
+func (playlist *MediaPlaylist) firstSequence() int64 {
 	sequence := playlist.Tags.MediaSequence()
+	if skip := playlist.Tags.Skip(); skip != nil {
+		if skipped, err := skip.SkippedSegments(); err == nil {
+			sequence += skipped
+		}
+	}
+	return sequence
+}
+
+// setSequences sets Sequence and DiscontinuitySequence of all the segments.
+func (playlist *MediaPlaylist) setSequences() {
+	sequence := playlist.firstSequence()
 	discSequence := playlist.Tags.DiscontinuitySequence()
-	for _, segment := range playlist.Segments {
+	for _, segment := range playlist.allSegments() {
 		if _, exists := segment.Tags[TagExtXDiscontinuity]; exists {
 			discSequence++
 		}
@@ -89,33 +306,99 @@ func DecodeMediaPlaylist(r io.Reader) (*MediaPlaylist, error) {
 		segment.DiscontinuitySequence = discSequence
 		sequence++
 	}
-	if len(segmentTags) != 0 {
-		return &playlist, ErrUnexpectedSegmentTags
-	}
-	return &playlist, nil
 }
 
-// Encode encodes a media playlist to io.Writer.
-func (playlist *MediaPlaylist) Encode(w io.Writer) error {
-	for _, tag := range playlist.Tags.Raw().List() {
-		err := tag.Encode(w)
-		if err != nil {
-			return err
+// SegmentBitrate returns the bitrate in kilobits per second which applies to the segment at the index.
+// The index of len(Segments) refers to PartialSegment.
+// An EXT-X-BITRATE tag applies to its segment and all the following segments
+// until the next EXT-X-BITRATE tag, except the segments with an EXT-X-BYTERANGE tag.
+// It returns false if no EXT-X-BITRATE tag applies to the segment, if the index is out of range,
+// or if an EXT-X-BITRATE tag up to the segment is invalid. Resolve reports such a tag as an error.
+// In a delta update, EXT-X-BITRATE tags in the skipped segments are not taken into account.
+func (playlist *MediaPlaylist) SegmentBitrate(index int) (int64, bool) {
+	segments := playlist.allSegments()
+	if index < 0 || index >= len(segments) {
+		return 0, false
+	}
+	var state bitrateState
+	for _, segment := range segments[:index] {
+		if _, _, err := state.next(segment); err != nil {
+			return 0, false
 		}
 	}
-	for _, segment := range playlist.Segments {
-		for _, tag := range segment.Tags.Raw().List() {
-			err := tag.Encode(w)
-			if err != nil {
-				return err
-			}
+	bitrate, ok, err := state.next(segments[index])
+	return bitrate, ok && err == nil
+}
+
+// bitrateState tracks the EXT-X-BITRATE tag which applies to the following segments.
+type bitrateState struct {
+	bitrate int64
+	ok      bool
+}
+
+// next updates the state with the tags of the segment and returns the bitrate which applies to it.
+// The EXT-X-BITRATE tag does not apply to a segment with an EXT-X-BYTERANGE tag.
+func (state *bitrateState) next(segment *Segment) (int64, bool, error) {
+	bitrate, ok, err := segment.Tags.bitrate()
+	if err != nil {
+		return 0, false, err
+	} else if ok {
+		state.bitrate = bitrate
+		state.ok = true
+	}
+	if _, ok := segment.Tags[TagExtXByteRange]; ok || !state.ok {
+		return 0, false, nil
+	}
+	return state.bitrate, true, nil
+}
+
+// allSegments returns the segments including the partial segment.
+func (playlist *MediaPlaylist) allSegments() []*Segment {
+	if playlist.PartialSegment == nil {
+		return playlist.Segments
+	}
+	segments := make([]*Segment, 0, len(playlist.Segments)+1)
+	segments = append(segments, playlist.Segments...)
+	return append(segments, playlist.PartialSegment)
+}
+
+// isFloatingSegmentTag reports whether the tag is written where it was decoded
+// among the segment tags even if TagOrder is nil.
+func isFloatingSegmentTag(name string) bool {
+	return name == TagExtXPart || name == CommentMarker
+}
+
+// isComment reports whether the name is CommentMarker.
+func isComment(name string) bool {
+	return name == CommentMarker
+}
+
+func (segment *Segment) encode(w io.Writer, registry *TagRegistry) error {
+	tags := segment.Tags.Raw().withComments(segment.Comments)
+	if len(segment.Parts) != 0 {
+		if len(segment.Comments) == 0 {
+			tags = tags.Clone()
 		}
-		if _, err := fmt.Fprintf(w, "%s\n", segment.URI); err != nil {
-			return err
+		for _, part := range segment.Parts {
+			tags.Add(&Tag{
+				Name:       TagExtXPart,
+				Attributes: encodeAttributes(Attributes(part.Attributes), part.AttributeOrder),
+			})
 		}
 	}
-	if playlist.EndList {
-		if _, err := w.Write([]byte("#" + TagExtXEndlist + "\n")); err != nil {
+	var list []*Tag
+	if segment.TagOrder != nil {
+		list = tags.listInOrder(registry, segment.TagOrder)
+	} else if segment.positions != nil {
+		list = tags.listAnchored(registry, segment.positions, isFloatingSegmentTag)
+	} else {
+		list = tags.list(registry)
+	}
+	if err := encodeTagList(w, list); err != nil {
+		return err
+	}
+	if segment.URI != "" {
+		if _, err := fmt.Fprintf(w, "%s\n", segment.URI); err != nil {
 			return err
 		}
 	}
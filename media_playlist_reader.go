@@ -0,0 +1,420 @@
+package m3u8
+
+import (
+	"io"
+)
+
+// MediaPlaylistReader reads a media playlist segment by segment.
+// It keeps only the segment being read in memory, so that a large media
+// playlist can be processed in constant memory.
+type MediaPlaylistReader struct {
+	d        *decodeState
+	lexer    *Lexer
+	playlist MediaPlaylist
+
+	segmentTags     SegmentTags
+	parts           []*Part
+	segmentTagOrder []string
+	segmentTagCount int
+	segmentComments []string
+
+	// playlistTagCount is the number of tags and comments counted toward MaxPlaylistTags.
+	playlistTagCount int
+
+	// comments are the comments which are not attached to the playlist or a segment yet.
+	comments []string
+
+	// next is the segment which is read ahead by NewMediaPlaylistReader.
+	next *Segment
+
+	segments     int
+	sequence     int64
+	discSequence int64
+	err          error
+}
+
+// NewMediaPlaylistReader creates a MediaPlaylistReader with the options.
+// It reads the media playlist until the first segment, so that Tags returns
+// the tags in the header.
+func NewMediaPlaylistReader(r io.Reader, opts *DecodeOptions) (*MediaPlaylistReader, error) {
+	reader, err := newMediaPlaylistReader(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// newMediaPlaylistReader is like NewMediaPlaylistReader,
+// but it returns the reader even if it fails, so that Playlist returns the tags read so far.
+func newMediaPlaylistReader(r io.Reader, opts *DecodeOptions) (*MediaPlaylistReader, error) {
+	d := newDecodeState(opts)
+	reader := &MediaPlaylistReader{
+		d:           d,
+		lexer:       NewLexer(r, d.opts),
+		segmentTags: make(SegmentTags),
+	}
+	reader.playlist.Tags = make(MediaPlaylistTags)
+	reader.playlist.registry = d.opts.tagRegistry()
+	if d.opts.PreserveTagOrder {
+		reader.playlist.TagOrder = make([]string, 0)
+		reader.segmentTagOrder = make([]string, 0)
+	}
+	for reader.segmentTagCount == 0 {
+		segment, ok, err := reader.readLine()
+		if err != nil {
+			reader.err = err
+			return reader, err
+		} else if !ok {
+			break
+		} else if segment != nil {
+			reader.next = segment
+			break
+		}
+	}
+	return reader, nil
+}
+
+// Tags returns the tags of the media playlist read so far.
+// The tags which precede the first segment are available right after NewMediaPlaylistReader.
+func (r *MediaPlaylistReader) Tags() MediaPlaylistTags {
+	return r.playlist.Tags
+}
+
+// Next returns the next segment with Sequence and DiscontinuitySequence.
+// It returns io.EOF at the end of the media playlist.
+// If segment tags remain without a segment URI at the end, it returns ErrUnexpectedSegmentTags
+// instead of io.EOF unless the mode is DecodeModeLenient.
+func (r *MediaPlaylistReader) Next() (*Segment, error) {
+	if r.next != nil {
+		segment := r.next
+		r.next = nil
+		r.setSequences(segment)
+		return segment, nil
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	for {
+		segment, ok, err := r.readLine()
+		if err != nil {
+			r.err = err
+			return nil, err
+		} else if !ok {
+			r.err = r.finish()
+			return nil, r.err
+		} else if segment != nil {
+			r.setSequences(segment)
+			return segment, nil
+		}
+	}
+}
+
+// Playlist returns the media playlist read so far without Segments.
+// After Next returns io.EOF, it also contains PartialSegment, TrailingTags, PreloadHints,
+// RenditionReports, EndList and Warnings of the whole media playlist.
+func (r *MediaPlaylistReader) Playlist() *MediaPlaylist {
+	r.playlist.Warnings = r.d.warnings
+	return &r.playlist
+}
+
+func (r *MediaPlaylistReader) setSequences(segment *Segment) {
+	if r.segments == 0 {
+		r.sequence = r.playlist.firstSequence()
+		r.discSequence = r.playlist.Tags.DiscontinuitySequence()
+	}
+	if _, exists := segment.Tags[TagExtXDiscontinuity]; exists {
+		r.discSequence++
+	}
+	segment.Sequence = r.sequence
+	segment.DiscontinuitySequence = r.discSequence
+	r.sequence++
+	r.segments++
+}
+
+// finish handles the end of the media playlist and returns the error which Next returns.
+func (r *MediaPlaylistReader) finish() error {
+	if len(r.parts) != 0 {
+		r.playlist.PartialSegment = &Segment{
+			Tags:     r.segmentTags,
+			Parts:    r.parts,
+			Comments: r.segmentComments,
+		}
+		r.setSegmentOrder(r.playlist.PartialSegment)
+		r.setSequences(r.playlist.PartialSegment)
+		r.segmentTags = make(SegmentTags)
+	}
+	r.attachTrailingComments()
+	if len(r.segmentTags) != 0 {
+		if r.d.opts.Mode != DecodeModeLenient {
+			return ErrUnexpectedSegmentTags
+		}
+		r.d.warnings = append(r.d.warnings, ErrUnexpectedSegmentTags)
+	}
+	return io.EOF
+}
+
+// readLine reads a line and returns the segment if the line completes it.
+// It returns false at the end of the input.
+func (r *MediaPlaylistReader) readLine() (*Segment, bool, error) {
+	d := r.d
+	opts := d.opts
+	token, err := r.lexer.Next()
+	if err == io.EOF {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	lineNumber := token.Line
+	line := token.Raw
+	if err := d.checkHeader(token); err != nil {
+		return nil, false, err
+	}
+	tagName := token.Name
+	if token.Type == TokenBlank {
+		return nil, true, nil
+	} else if token.Type == TokenComment {
+		if r.inHeader() {
+			err = r.countPlaylistTag(lineNumber)
+		} else {
+			err = r.countSegmentTag(lineNumber)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		r.comments = append(r.comments, line[1:])
+		return nil, true, nil
+	}
+	if token.Type == TokenURI {
+		r.attachSegmentComments()
+		if d.validates() && len(r.segmentTags[TagExtInf]) == 0 {
+			if err := d.report(newDecodeError(lineNumber, line, "", ErrMissingExtInf), false); err != nil {
+				return nil, false, err
+			}
+		}
+		if err := d.checkCount(lineNumber, "MaxSegments", r.segments+1, opts.MaxSegments); err != nil {
+			return nil, false, err
+		}
+		segment := &Segment{
+			Tags:     r.segmentTags,
+			URI:      line,
+			Parts:    r.parts,
+			Comments: r.segmentComments,
+		}
+		r.setSegmentOrder(segment)
+		r.segmentTags = make(SegmentTags)
+		r.segmentComments = nil
+		r.parts = nil
+		r.segmentTagCount = 0
+		return segment, true, nil
+	} else if tagName == TagExtXPart {
+		r.attachSegmentComments()
+		attrs, attrOrder, err := opts.parseTagAttributes(AttributeString(line))
+		if err != nil {
+			return nil, true, d.report(newDecodeError(lineNumber, line, tagName, err), true)
+		}
+		if err := r.countSegmentTag(lineNumber); err != nil {
+			return nil, false, err
+		}
+		r.parts = append(r.parts, &Part{
+			Attributes:     PartAttrs(attrs),
+			AttributeOrder: attrOrder,
+		})
+		r.segmentTagOrder = append(r.segmentTagOrder, tagName)
+	} else if tagName == TagExtXPreloadHint {
+		attrs, attrOrder, err := opts.parseTagAttributes(AttributeString(line))
+		if err != nil {
+			return nil, true, d.report(newDecodeError(lineNumber, line, tagName, err), true)
+		}
+		if err := r.countPlaylistTag(lineNumber); err != nil {
+			return nil, false, err
+		}
+		r.addTrailer(tagName)
+		r.playlist.PreloadHints = append(r.playlist.PreloadHints, &PreloadHint{
+			Attributes:     PreloadHintAttrs(attrs),
+			AttributeOrder: attrOrder,
+		})
+	} else if tagName == TagExtXRenditionReport {
+		attrs, attrOrder, err := opts.parseTagAttributes(AttributeString(line))
+		if err != nil {
+			return nil, true, d.report(newDecodeError(lineNumber, line, tagName, err), true)
+		}
+		if err := r.countPlaylistTag(lineNumber); err != nil {
+			return nil, false, err
+		}
+		r.addTrailer(tagName)
+		r.playlist.RenditionReports = append(r.playlist.RenditionReports, &RenditionReport{
+			Attributes:     RenditionReportAttrs(attrs),
+			AttributeOrder: attrOrder,
+		})
+	} else if opts.tagRegistry().Definition(tagName).Scope == TagScopeSegment || r.isSegmentVendorTag(tagName) {
+		r.attachSegmentComments()
+		if err := d.checkTag(lineNumber, line, tagName); err != nil {
+			return nil, false, err
+		}
+		if err := d.checkDuplicate(lineNumber, line, tagName, r.segmentTags.Raw()); err != nil {
+			return nil, false, err
+		}
+		if err := r.countSegmentTag(lineNumber); err != nil {
+			return nil, false, err
+		}
+		r.segmentTags.Raw().Add(&Tag{
+			Name:       tagName,
+			Attributes: AttributeString(line),
+		})
+		r.segmentTagOrder = append(r.segmentTagOrder, tagName)
+	} else if tagName == TagExtXEndlist {
+		if err := r.countPlaylistTag(lineNumber); err != nil {
+			return nil, false, err
+		}
+		r.playlist.EndList = true
+		r.addTrailer(tagName)
+	} else {
+		if err := r.countPlaylistTag(lineNumber); err != nil {
+			return nil, false, err
+		}
+		if err := d.checkTag(lineNumber, line, tagName); err != nil {
+			return nil, false, err
+		}
+		if err := d.checkDuplicate(lineNumber, line, tagName, r.playlist.Tags.Raw()); err != nil {
+			return nil, false, err
+		}
+		r.attachPlaylistComments()
+		r.playlist.Tags.Raw().Add(&Tag{
+			Name:       tagName,
+			Attributes: AttributeString(line),
+		})
+		r.addPlaylistTagOrder(tagName)
+	}
+	return nil, true, nil
+}
+
+// isSegmentVendorTag reports whether the tag is an unregistered tag which appears after
+// the first segment has started. Such a tag is attached to the segment being read,
+// so that Encode writes it back among the segments.
+func (r *MediaPlaylistReader) isSegmentVendorTag(name string) bool {
+	if r.inHeader() {
+		return false
+	}
+	_, ok := r.d.opts.tagRegistry().Lookup(name)
+	return !ok
+}
+
+// inHeader reports whether no segment has started yet.
+func (r *MediaPlaylistReader) inHeader() bool {
+	return r.segments == 0 && r.next == nil && r.segmentTagCount == 0
+}
+
+// countSegmentTag counts a tag or a comment of the segment being read toward MaxTagsPerSegment.
+func (r *MediaPlaylistReader) countSegmentTag(lineNumber int) error {
+	r.segmentTagCount++
+	return r.d.checkCount(lineNumber, "MaxTagsPerSegment", r.segmentTagCount, r.d.opts.MaxTagsPerSegment)
+}
+
+// countPlaylistTag counts a tag or a comment which is not applied to a segment toward MaxPlaylistTags.
+func (r *MediaPlaylistReader) countPlaylistTag(lineNumber int) error {
+	r.playlistTagCount++
+	return r.d.checkCount(lineNumber, "MaxPlaylistTags", r.playlistTagCount, r.d.opts.MaxPlaylistTags)
+}
+
+// hasOnlyVendorTags reports whether the tags of the segment being read are all unregistered.
+// It returns false if there is no such tag or the segment has EXT-X-PART tags.
+func (r *MediaPlaylistReader) hasOnlyVendorTags() bool {
+	if len(r.segmentTags) == 0 || len(r.parts) != 0 {
+		return false
+	}
+	for name := range r.segmentTags {
+		if _, ok := r.d.opts.tagRegistry().Lookup(name); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// moveTrailingTags moves the unregistered tags which follow the last segment,
+// and the comments among them, to TrailingTags and TrailingComments of the media playlist.
+func (r *MediaPlaylistReader) moveTrailingTags() {
+	used := make(map[string]int, len(r.segmentTags))
+	comments := 0
+	for _, name := range r.segmentTagOrder {
+		if name == CommentMarker {
+			r.playlist.TrailingComments = append(r.playlist.TrailingComments, r.segmentComments[comments])
+			comments++
+		} else {
+			r.playlist.TrailingTags = append(r.playlist.TrailingTags, &Tag{
+				Name:       name,
+				Attributes: r.segmentTags[name][used[name]],
+			})
+			used[name]++
+			name = trailingTagMarker
+		}
+		r.playlist.trailer = append(r.playlist.trailer, name)
+	}
+	r.segmentTags = make(SegmentTags)
+	r.segmentComments = nil
+	r.segmentTagOrder = r.segmentTagOrder[:0]
+	r.segmentTagCount = 0
+}
+
+// setSegmentOrder sets the order of the tags read for the segment and starts a new one.
+// Without PreserveTagOrder, the order is kept only if the segment has EXT-X-PART tags
+// or comments, which Encode writes back where they were, or if Resolve needs to know
+// whether EXT-X-MAP precedes EXT-X-KEY.
+func (r *MediaPlaylistReader) setSegmentOrder(segment *Segment) {
+	if r.d.opts.PreserveTagOrder {
+		segment.TagOrder = r.segmentTagOrder
+		r.segmentTagOrder = make([]string, 0)
+	} else if len(segment.Parts) != 0 || len(segment.Comments) != 0 || segment.hasKeyAndMap() {
+		segment.positions = r.segmentTagOrder
+		r.segmentTagOrder = nil
+	} else {
+		r.segmentTagOrder = r.segmentTagOrder[:0]
+	}
+}
+
+// attachSegmentComments attaches the pending comments to the segment being read.
+func (r *MediaPlaylistReader) attachSegmentComments() {
+	r.segmentComments = append(r.segmentComments, r.comments...)
+	for range r.comments {
+		r.segmentTagOrder = append(r.segmentTagOrder, CommentMarker)
+	}
+	r.comments = nil
+}
+
+// attachPlaylistComments attaches the pending comments to the media playlist.
+func (r *MediaPlaylistReader) attachPlaylistComments() {
+	r.playlist.Comments = append(r.playlist.Comments, r.comments...)
+	for range r.comments {
+		r.addPlaylistTagOrder(CommentMarker)
+	}
+	r.comments = nil
+}
+
+// attachTrailingComments attaches the pending comments to TrailingComments of the media playlist.
+// The unregistered tags read since the last segment precede them.
+func (r *MediaPlaylistReader) attachTrailingComments() {
+	if r.hasOnlyVendorTags() {
+		r.moveTrailingTags()
+	}
+	r.playlist.TrailingComments = append(r.playlist.TrailingComments, r.comments...)
+	for range r.comments {
+		r.playlist.trailer = append(r.playlist.trailer, CommentMarker)
+	}
+	r.comments = nil
+}
+
+// addTrailer records the name of a tag which Encode writes after the segments.
+// The pending comments precede it.
+func (r *MediaPlaylistReader) addTrailer(name string) {
+	r.attachTrailingComments()
+	r.playlist.trailer = append(r.playlist.trailer, name)
+}
+
+// addPlaylistTagOrder records the name of a tag of the media playlist to TagOrder
+// with PreserveTagOrder, or to positions without it.
+func (r *MediaPlaylistReader) addPlaylistTagOrder(name string) {
+	if r.d.opts.PreserveTagOrder {
+		r.playlist.TagOrder = append(r.playlist.TagOrder, name)
+	} else {
+		r.playlist.positions = append(r.playlist.positions, name)
+	}
+}
@@ -0,0 +1,109 @@
+package m3u8
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaPlaylistReader(t *testing.T) {
+	t.Run("segments", func(t *testing.T) {
+		reader, err := NewMediaPlaylistReader(bytes.NewReader([]byte(sampleMedia03Input)), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 10, reader.Tags().TargetDuration())
+		assert.Equal(t, 3, reader.Tags().Version())
+
+		var uris []string
+		var discSequences []int64
+		for {
+			segment, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(uris)), segment.Sequence)
+			uris = append(uris, segment.URI)
+			discSequences = append(discSequences, segment.DiscontinuitySequence)
+		}
+		assert.Equal(t, []string{
+			"http://media.example.com/sequence1-A.ts",
+			"http://media.example.com/sequence1-B.ts",
+			"http://media.example.com/sequence1-C.ts",
+			"http://media.example.com/sequence2-A.ts",
+		}, uris)
+		assert.Equal(t, []int64{0, 0, 0, 1}, discSequences)
+		assert.True(t, reader.Playlist().EndList)
+		assert.Empty(t, reader.Playlist().Segments)
+
+		segment, err := reader.Next()
+		assert.Nil(t, segment)
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("low_latency", func(t *testing.T) {
+		reader, err := NewMediaPlaylistReader(bytes.NewReader([]byte(sampleLowLatencyInput)), nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(271), reader.Tags().MediaSequence())
+
+		var segments []*Segment
+		for {
+			segment, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			segments = append(segments, segment)
+		}
+		require.Len(t, segments, 2)
+		assert.Equal(t, int64(271), segments[0].Sequence)
+		assert.Equal(t, int64(272), segments[1].Sequence)
+		assert.Len(t, segments[1].Parts, 2)
+
+		playlist := reader.Playlist()
+		require.NotNil(t, playlist.PartialSegment)
+		assert.Equal(t, int64(273), playlist.PartialSegment.Sequence)
+		assert.Equal(t, int64(1), playlist.PartialSegment.DiscontinuitySequence)
+		assert.Len(t, playlist.PreloadHints, 1)
+		assert.Len(t, playlist.RenditionReports, 2)
+	})
+
+	t.Run("no_segments", func(t *testing.T) {
+		reader, err := NewMediaPlaylistReader(bytes.NewReader([]byte("#EXTM3U\n#EXT-X-TARGETDURATION:6\n")), nil)
+		require.NoError(t, err)
+		assert.Equal(t, 6, reader.Tags().TargetDuration())
+		_, err = reader.Next()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("unexpected_segment_tags", func(t *testing.T) {
+		input := "#EXTM3U\n#EXTINF:6.000,\nseg0.ts\n#EXTINF:6.000,\n"
+		reader, err := NewMediaPlaylistReader(bytes.NewReader([]byte(input)), nil)
+		require.NoError(t, err)
+		segment, err := reader.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "seg0.ts", segment.URI)
+		_, err = reader.Next()
+		assert.ErrorIs(t, err, ErrUnexpectedSegmentTags)
+	})
+
+	t.Run("header_error", func(t *testing.T) {
+		input := "#EXTM3U\n#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"part.mp4\n#EXTINF:6.000,\nseg0.ts\n"
+		_, err := NewMediaPlaylistReader(bytes.NewReader([]byte(input)), nil)
+		assert.ErrorIs(t, err, ErrInvalidAttributes)
+	})
+
+	t.Run("segment_error", func(t *testing.T) {
+		input := "#EXTM3U\n#EXTINF:6.000,\nseg0.ts\n#EXT-X-PART:URI=\"part.mp4\n"
+		reader, err := NewMediaPlaylistReader(bytes.NewReader([]byte(input)), nil)
+		require.NoError(t, err)
+		_, err = reader.Next()
+		require.NoError(t, err)
+		_, err = reader.Next()
+		assert.ErrorIs(t, err, ErrInvalidAttributes)
+		_, err = reader.Next()
+		assert.ErrorIs(t, err, ErrInvalidAttributes)
+	})
+}
@@ -1,8 +1,7 @@
 package m3u8
 
 import (
-	"encoding/hex"
-	"errors"
+	"encoding/binary"
 	"strconv"
 	"strings"
 	"time"
@@ -60,6 +59,11 @@ func (tags MediaPlaylistTags) Version() int {
 	return version
 }
 
+// SetVersion sets the value of the EXT-X-VERSION tag.
+func (tags MediaPlaylistTags) SetVersion(version int) {
+	tags[TagExtXVersion] = []string{strconv.Itoa(version)}
+}
+
 // TargetDuration returns the value of the EXT-X-TARGETDURATION tag.
 func (tags MediaPlaylistTags) TargetDuration() int {
 	values, ok := tags[TagExtXTargetDuration]
@@ -136,30 +140,215 @@ func (tags MediaPlaylistTags) SetDiscontinuitySequence(sequence int64) {
 	tags[TagExtXDiscontinuitySequence] = []string{strconv.FormatInt(sequence, 10)}
 }
 
+// ServerControl returns the attributes of the EXT-X-SERVER-CONTROL tag.
+// If the tag does not exist or is invalid, it returns nil.
+func (tags MediaPlaylistTags) ServerControl() ServerControlAttrs {
+	return ServerControlAttrs(tags.attributes(TagExtXServerControl))
+}
+
+// SetServerControl sets the attributes of the EXT-X-SERVER-CONTROL tag.
+func (tags MediaPlaylistTags) SetServerControl(attrs ServerControlAttrs) {
+	tags[TagExtXServerControl] = []string{Attributes(attrs).String()}
+}
+
+// PartInf returns the attributes of the EXT-X-PART-INF tag.
+// If the tag does not exist or is invalid, it returns nil.
+func (tags MediaPlaylistTags) PartInf() PartInfAttrs {
+	return PartInfAttrs(tags.attributes(TagExtXPartInf))
+}
+
+// SetPartInf sets the attributes of the EXT-X-PART-INF tag.
+func (tags MediaPlaylistTags) SetPartInf(attrs PartInfAttrs) {
+	tags[TagExtXPartInf] = []string{Attributes(attrs).String()}
+}
+
+// Skip returns the attributes of the EXT-X-SKIP tag.
+// If the tag does not exist or is invalid, it returns nil.
+func (tags MediaPlaylistTags) Skip() SkipAttrs {
+	return SkipAttrs(tags.attributes(TagExtXSkip))
+}
+
+// SetSkip sets the attributes of the EXT-X-SKIP tag.
+func (tags MediaPlaylistTags) SetSkip(attrs SkipAttrs) {
+	tags[TagExtXSkip] = []string{Attributes(attrs).String()}
+}
+
+// RemoveSkip removes the EXT-X-SKIP tag.
+func (tags MediaPlaylistTags) RemoveSkip() {
+	delete(tags, TagExtXSkip)
+}
+
+func (tags MediaPlaylistTags) attributes(name string) Attributes {
+	values, ok := tags[name]
+	if !ok || len(values) == 0 {
+		return nil
+	}
+	attrs, err := ParseTagAttributes(values[0])
+	if err != nil {
+		return nil
+	}
+	return attrs
+}
+
+// ServerControlAttrs represents the attributes of the EXT-X-SERVER-CONTROL tag.
+type ServerControlAttrs Attributes
+
+// CanBlockReload returns the value of the CAN-BLOCK-RELOAD attribute.
+func (attrs ServerControlAttrs) CanBlockReload() bool {
+	return attrs["CAN-BLOCK-RELOAD"] == "YES"
+}
+
+// SetCanBlockReload sets the value of the CAN-BLOCK-RELOAD attribute.
+// If canBlockReload is false, the attribute is removed.
+func (attrs ServerControlAttrs) SetCanBlockReload(canBlockReload bool) {
+	if canBlockReload {
+		attrs["CAN-BLOCK-RELOAD"] = "YES"
+	} else {
+		delete(attrs, "CAN-BLOCK-RELOAD")
+	}
+}
+
+// CanSkipUntil returns the value of the CAN-SKIP-UNTIL attribute.
+// If the attribute does not exist, it returns 0.
+func (attrs ServerControlAttrs) CanSkipUntil() (float64, error) {
+	value := attrs["CAN-SKIP-UNTIL"]
+	if value == "" {
+		return 0, nil
+	}
+	return AttributeValue(value).DecimalFloatingPoint()
+}
+
+// SetCanSkipUntil sets the value of the CAN-SKIP-UNTIL attribute.
+func (attrs ServerControlAttrs) SetCanSkipUntil(seconds float64) {
+	attrs["CAN-SKIP-UNTIL"] = string(EncodeDecimalFloatingPoint(seconds))
+}
+
+// CanSkipDateRanges returns the value of the CAN-SKIP-DATERANGES attribute.
+func (attrs ServerControlAttrs) CanSkipDateRanges() bool {
+	return attrs["CAN-SKIP-DATERANGES"] == "YES"
+}
+
+// SetCanSkipDateRanges sets the value of the CAN-SKIP-DATERANGES attribute.
+// If canSkipDateRanges is false, the attribute is removed.
+func (attrs ServerControlAttrs) SetCanSkipDateRanges(canSkipDateRanges bool) {
+	if canSkipDateRanges {
+		attrs["CAN-SKIP-DATERANGES"] = "YES"
+	} else {
+		delete(attrs, "CAN-SKIP-DATERANGES")
+	}
+}
+
+// HoldBack returns the value of the HOLD-BACK attribute.
+// If the attribute does not exist, it returns 0.
+func (attrs ServerControlAttrs) HoldBack() (float64, error) {
+	value := attrs["HOLD-BACK"]
+	if value == "" {
+		return 0, nil
+	}
+	return AttributeValue(value).DecimalFloatingPoint()
+}
+
+// SetHoldBack sets the value of the HOLD-BACK attribute.
+func (attrs ServerControlAttrs) SetHoldBack(seconds float64) {
+	attrs["HOLD-BACK"] = string(EncodeDecimalFloatingPoint(seconds))
+}
+
+// PartHoldBack returns the value of the PART-HOLD-BACK attribute.
+// If the attribute does not exist, it returns 0.
+func (attrs ServerControlAttrs) PartHoldBack() (float64, error) {
+	value := attrs["PART-HOLD-BACK"]
+	if value == "" {
+		return 0, nil
+	}
+	return AttributeValue(value).DecimalFloatingPoint()
+}
+
+// SetPartHoldBack sets the value of the PART-HOLD-BACK attribute.
+func (attrs ServerControlAttrs) SetPartHoldBack(seconds float64) {
+	attrs["PART-HOLD-BACK"] = string(EncodeDecimalFloatingPoint(seconds))
+}
+
+// PartInfAttrs represents the attributes of the EXT-X-PART-INF tag.
+type PartInfAttrs Attributes
+
+// PartTarget returns the value of the PART-TARGET attribute.
+func (attrs PartInfAttrs) PartTarget() (float64, error) {
+	return AttributeValue(attrs["PART-TARGET"]).DecimalFloatingPoint()
+}
+
+// SetPartTarget sets the value of the PART-TARGET attribute.
+func (attrs PartInfAttrs) SetPartTarget(seconds float64) {
+	attrs["PART-TARGET"] = string(EncodeDecimalFloatingPoint(seconds))
+}
+
+// SkipAttrs represents the attributes of the EXT-X-SKIP tag.
+type SkipAttrs Attributes
+
+// SkippedSegments returns the value of the SKIPPED-SEGMENTS attribute.
+func (attrs SkipAttrs) SkippedSegments() (int64, error) {
+	return decimalInteger64(attrs["SKIPPED-SEGMENTS"])
+}
+
+// SetSkippedSegments sets the value of the SKIPPED-SEGMENTS attribute.
+func (attrs SkipAttrs) SetSkippedSegments(count int64) {
+	attrs["SKIPPED-SEGMENTS"] = strconv.FormatInt(count, 10)
+}
+
+// RecentlyRemovedDateRanges returns the IDs listed in the RECENTLY-REMOVED-DATERANGES attribute.
+func (attrs SkipAttrs) RecentlyRemovedDateRanges() []string {
+	value := AttributeValue(attrs["RECENTLY-REMOVED-DATERANGES"]).unquote()
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, "\t")
+}
+
+// SetRecentlyRemovedDateRanges sets the IDs of the RECENTLY-REMOVED-DATERANGES attribute.
+// If ids is empty, the attribute is removed.
+func (attrs SkipAttrs) SetRecentlyRemovedDateRanges(ids []string) {
+	if len(ids) == 0 {
+		delete(attrs, "RECENTLY-REMOVED-DATERANGES")
+		return
+	}
+	attrs["RECENTLY-REMOVED-DATERANGES"] = string(EncodeQuotedString(strings.Join(ids, "\t")))
+}
+
 // DateRangeAttrs represents the attributes of the EXT-X-DATERANGE tag.
 type DateRangeAttrs Attributes
 
+// StringInOrder encodes the attributes to a string in the order of the keys.
+// See Attributes#StringInOrder.
+func (attrs DateRangeAttrs) StringInOrder(order []string) string {
+	return Attributes(attrs).StringInOrder(order)
+}
+
 // EventID returns the value of the ID attribute.
 func (attrs DateRangeAttrs) EventID() string {
-	return strings.Trim(attrs["ID"], `"`)
+	return AttributeValue(attrs["ID"]).unquote()
 }
 
 // StartDate returns the value of the START-DATE attribute.
 func (attrs DateRangeAttrs) StartDate() (time.Time, error) {
-	value := strings.Trim(attrs["START-DATE"], `"`)
-	if value == "" {
-		return time.Time{}, nil
-	}
-	return time.Parse(time.RFC3339Nano, value)
+	return attrs.date("START-DATE")
 }
 
 // EndDate returns the value of the END-DATE attribute.
 func (attrs DateRangeAttrs) EndDate() (time.Time, error) {
-	value := strings.Trim(attrs["END-DATE"], `"`)
-	if value == "" {
+	return attrs.date("END-DATE")
+}
+
+// date returns the value of the attribute as a date.
+// It returns the zero time if the attribute does not exist or is empty.
+func (attrs DateRangeAttrs) date(key string) (time.Time, error) {
+	value := attrs[key]
+	if value == "" || value == `""` {
 		return time.Time{}, nil
 	}
-	return time.Parse(time.RFC3339Nano, value)
+	date, err := AttributeValue(value).QuotedString()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, date)
 }
 
 // Duration returns the value of the DURATION attribute.
@@ -168,7 +357,7 @@ func (attrs DateRangeAttrs) Duration() (float64, error) {
 	if value == "" {
 		return 0, nil
 	}
-	return strconv.ParseFloat(value, 64)
+	return AttributeValue(value).DecimalFloatingPoint()
 }
 
 // PlannedDuration returns the value of the PLANNED-DURATION attribute.
@@ -177,7 +366,7 @@ func (attrs DateRangeAttrs) PlannedDuration() (float64, error) {
 	if value == "" {
 		return 0, nil
 	}
-	return strconv.ParseFloat(value, 64)
+	return AttributeValue(value).DecimalFloatingPoint()
 }
 
 // SCTE35Out returns the value of the SCTE35-OUT attribute.
@@ -186,10 +375,26 @@ func (attrs DateRangeAttrs) SCTE35Out() ([]byte, error) {
 	if value == "" {
 		return nil, nil
 	}
-	if !strings.HasPrefix(value, "0x") {
-		return nil, errors.New("unknown prefix")
-	}
-	return hex.DecodeString(value[2:])
+	return AttributeValue(value).HexadecimalSequence()
+}
+
+var dateRangeAttrTypes = map[string]AttributeValueType{
+	"ID":               AttributeTypeQuotedString,
+	"CLASS":            AttributeTypeQuotedString,
+	"START-DATE":       AttributeTypeQuotedString,
+	"CUE":              AttributeTypeQuotedString,
+	"END-DATE":         AttributeTypeQuotedString,
+	"DURATION":         AttributeTypeDecimalFloatingPoint,
+	"PLANNED-DURATION": AttributeTypeDecimalFloatingPoint,
+	"SCTE35-CMD":       AttributeTypeHexadecimalSequence,
+	"SCTE35-OUT":       AttributeTypeHexadecimalSequence,
+	"SCTE35-IN":        AttributeTypeHexadecimalSequence,
+	"END-ON-NEXT":      AttributeTypeEnumeratedString,
+}
+
+// Validate checks the types of the known attributes.
+func (attrs DateRangeAttrs) Validate() error {
+	return validateAttributes(Attributes(attrs), dateRangeAttrTypes)
 }
 
 // DateRangeAttrValues represents the attribute values of the EXT-X-DATERANGE tag.
@@ -229,3 +434,384 @@ func (attrs DateRangeAttrs) Decode() (*DateRangeAttrValues, error) {
 	}
 	return values, nil
 }
+
+// PartAttrs represents the attributes of the EXT-X-PART tag.
+type PartAttrs Attributes
+
+// Duration returns the value of the DURATION attribute.
+func (attrs PartAttrs) Duration() (float64, error) {
+	return AttributeValue(attrs["DURATION"]).DecimalFloatingPoint()
+}
+
+// SetDuration sets the value of the DURATION attribute.
+func (attrs PartAttrs) SetDuration(duration float64) {
+	attrs["DURATION"] = string(EncodeDecimalFloatingPoint(duration))
+}
+
+// URI returns the value of the URI attribute.
+func (attrs PartAttrs) URI() string {
+	return AttributeValue(attrs["URI"]).unquote()
+}
+
+// SetURI sets the value of the URI attribute.
+func (attrs PartAttrs) SetURI(uri string) {
+	attrs["URI"] = string(EncodeQuotedString(uri))
+}
+
+// Independent returns the value of the INDEPENDENT attribute.
+func (attrs PartAttrs) Independent() bool {
+	return attrs["INDEPENDENT"] == "YES"
+}
+
+// SetIndependent sets the value of the INDEPENDENT attribute.
+// If independent is false, the attribute is removed.
+func (attrs PartAttrs) SetIndependent(independent bool) {
+	if independent {
+		attrs["INDEPENDENT"] = "YES"
+	} else {
+		delete(attrs, "INDEPENDENT")
+	}
+}
+
+// ByteRange returns the value of the BYTERANGE attribute.
+// If the attribute does not exist, it returns nil.
+func (attrs PartAttrs) ByteRange() (*ByteRange, error) {
+	value, ok := attrs["BYTERANGE"]
+	if !ok {
+		return nil, nil
+	}
+	byteRange, err := ParseByteRange(AttributeValue(value).unquote())
+	if err != nil {
+		return nil, err
+	}
+	return &byteRange, nil
+}
+
+// SetByteRange sets the value of the BYTERANGE attribute.
+func (attrs PartAttrs) SetByteRange(byteRange ByteRange) {
+	attrs["BYTERANGE"] = string(EncodeQuotedString(byteRange.String()))
+}
+
+// Gap returns the value of the GAP attribute.
+func (attrs PartAttrs) Gap() bool {
+	return attrs["GAP"] == "YES"
+}
+
+// SetGap sets the value of the GAP attribute.
+// If gap is false, the attribute is removed.
+func (attrs PartAttrs) SetGap(gap bool) {
+	if gap {
+		attrs["GAP"] = "YES"
+	} else {
+		delete(attrs, "GAP")
+	}
+}
+
+// KeyMethod represents the encryption method of the EXT-X-KEY tag.
+type KeyMethod string
+
+const (
+	KeyMethodNone         KeyMethod = "NONE"
+	KeyMethodAES128       KeyMethod = "AES-128"
+	KeyMethodSampleAES    KeyMethod = "SAMPLE-AES"
+	KeyMethodSampleAESCTR KeyMethod = "SAMPLE-AES-CTR"
+)
+
+// KeyFormatIdentity is the default value of the KEYFORMAT attribute.
+const KeyFormatIdentity = "identity"
+
+// KeyAttrs represents the attributes of the EXT-X-KEY tag.
+type KeyAttrs Attributes
+
+// Method returns the value of the METHOD attribute.
+func (attrs KeyAttrs) Method() KeyMethod {
+	return KeyMethod(attrs["METHOD"])
+}
+
+// SetMethod sets the value of the METHOD attribute.
+func (attrs KeyAttrs) SetMethod(method KeyMethod) {
+	attrs["METHOD"] = string(method)
+}
+
+// URI returns the value of the URI attribute.
+func (attrs KeyAttrs) URI() string {
+	return AttributeValue(attrs["URI"]).unquote()
+}
+
+// SetURI sets the value of the URI attribute.
+func (attrs KeyAttrs) SetURI(uri string) {
+	attrs["URI"] = string(EncodeQuotedString(uri))
+}
+
+// KeyFormat returns the value of the KEYFORMAT attribute.
+// If the attribute does not exist, it returns KeyFormatIdentity.
+func (attrs KeyAttrs) KeyFormat() string {
+	value, ok := attrs["KEYFORMAT"]
+	if !ok {
+		return KeyFormatIdentity
+	}
+	return AttributeValue(value).unquote()
+}
+
+// SetKeyFormat sets the value of the KEYFORMAT attribute.
+func (attrs KeyAttrs) SetKeyFormat(keyFormat string) {
+	attrs["KEYFORMAT"] = string(EncodeQuotedString(keyFormat))
+}
+
+// IV returns the value of the IV attribute.
+// If the attribute does not exist, it returns nil. See IVForSequence.
+func (attrs KeyAttrs) IV() (*[16]byte, error) {
+	value, ok := attrs["IV"]
+	if !ok {
+		return nil, nil
+	}
+	data, err := AttributeValue(value).HexadecimalSequence()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 16 {
+		return nil, AttributeValue(value).error(AttributeTypeHexadecimalSequence)
+	}
+	var iv [16]byte
+	copy(iv[:], data)
+	return &iv, nil
+}
+
+// SetIV sets the value of the IV attribute.
+func (attrs KeyAttrs) SetIV(iv [16]byte) {
+	attrs["IV"] = string(EncodeHexadecimalSequence(iv[:]))
+}
+
+// RemoveIV removes the IV attribute.
+func (attrs KeyAttrs) RemoveIV() {
+	delete(attrs, "IV")
+}
+
+// IVForSequence returns the initialization vector for the media segment with the sequence number.
+// If the IV attribute does not exist, it returns DefaultIV(sequence).
+func (attrs KeyAttrs) IVForSequence(sequence int64) ([16]byte, error) {
+	iv, err := attrs.IV()
+	if err != nil {
+		return [16]byte{}, err
+	}
+	if iv == nil {
+		return DefaultIV(sequence), nil
+	}
+	return *iv, nil
+}
+
+// DefaultIV returns the initialization vector used when the EXT-X-KEY tag has no IV attribute,
+// which is the media sequence number as a big-endian binary representation.
+func DefaultIV(sequence int64) [16]byte {
+	var iv [16]byte
+	binary.BigEndian.PutUint64(iv[8:], uint64(sequence))
+	return iv
+}
+
+// KeyFormatVersions returns the value of the KEYFORMATVERSIONS attribute.
+// If the attribute does not exist, it returns [1].
+func (attrs KeyAttrs) KeyFormatVersions() ([]int, error) {
+	value, ok := attrs["KEYFORMATVERSIONS"]
+	if !ok {
+		return []int{1}, nil
+	}
+	s, err := AttributeValue(value).QuotedString()
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Split(s, "/")
+	versions := make([]int, 0, len(fields))
+	for _, field := range fields {
+		version, err := strconv.Atoi(field)
+		if err != nil || version <= 0 {
+			return nil, AttributeValue(value).error(AttributeTypeQuotedString)
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// SetKeyFormatVersions sets the value of the KEYFORMATVERSIONS attribute.
+func (attrs KeyAttrs) SetKeyFormatVersions(versions []int) {
+	fields := make([]string, 0, len(versions))
+	for _, version := range versions {
+		fields = append(fields, strconv.Itoa(version))
+	}
+	attrs["KEYFORMATVERSIONS"] = string(EncodeQuotedString(strings.Join(fields, "/")))
+}
+
+var keyAttrTypes = map[string]AttributeValueType{
+	"METHOD":            AttributeTypeEnumeratedString,
+	"URI":               AttributeTypeQuotedString,
+	"IV":                AttributeTypeHexadecimalSequence,
+	"KEYFORMAT":         AttributeTypeQuotedString,
+	"KEYFORMATVERSIONS": AttributeTypeQuotedString,
+}
+
+// Validate checks the types of the known attributes and the existence of the METHOD attribute.
+func (attrs KeyAttrs) Validate() error {
+	if _, ok := attrs["METHOD"]; !ok {
+		return ErrMissingMethod
+	}
+	return validateAttributes(Attributes(attrs), keyAttrTypes)
+}
+
+// parseKeys parses the values of EXT-X-KEY or EXT-X-SESSION-KEY tags.
+// Invalid values are skipped.
+func parseKeys(values []string) []KeyAttrs {
+	keys := make([]KeyAttrs, 0, len(values))
+	for _, value := range values {
+		attrs, err := ParseTagAttributes(value)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, KeyAttrs(attrs))
+	}
+	return keys
+}
+
+// encodeKeys encodes the keys to the values of EXT-X-KEY or EXT-X-SESSION-KEY tags.
+func encodeKeys(keys []KeyAttrs) []string {
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, Attributes(key).String())
+	}
+	return values
+}
+
+// MapAttrs represents the attributes of the EXT-X-MAP tag.
+type MapAttrs Attributes
+
+// URI returns the value of the URI attribute.
+func (attrs MapAttrs) URI() string {
+	return AttributeValue(attrs["URI"]).unquote()
+}
+
+// SetURI sets the value of the URI attribute.
+func (attrs MapAttrs) SetURI(uri string) {
+	attrs["URI"] = string(EncodeQuotedString(uri))
+}
+
+// ByteRange returns the value of the BYTERANGE attribute.
+// If the attribute does not exist, it returns nil.
+func (attrs MapAttrs) ByteRange() (*ByteRange, error) {
+	value, ok := attrs["BYTERANGE"]
+	if !ok {
+		return nil, nil
+	}
+	byteRange, err := ParseByteRange(AttributeValue(value).unquote())
+	if err != nil {
+		return nil, err
+	}
+	return &byteRange, nil
+}
+
+// SetByteRange sets the value of the BYTERANGE attribute.
+func (attrs MapAttrs) SetByteRange(byteRange ByteRange) {
+	attrs["BYTERANGE"] = string(EncodeQuotedString(byteRange.String()))
+}
+
+// PreloadHintType represents the type of the resource hinted by the EXT-X-PRELOAD-HINT tag.
+type PreloadHintType string
+
+const (
+	PreloadHintTypePart PreloadHintType = "PART"
+	PreloadHintTypeMap  PreloadHintType = "MAP"
+)
+
+// PreloadHintAttrs represents the attributes of the EXT-X-PRELOAD-HINT tag.
+type PreloadHintAttrs Attributes
+
+// Type returns the value of the TYPE attribute.
+func (attrs PreloadHintAttrs) Type() PreloadHintType {
+	return PreloadHintType(attrs["TYPE"])
+}
+
+// SetType sets the value of the TYPE attribute.
+func (attrs PreloadHintAttrs) SetType(typ PreloadHintType) {
+	attrs["TYPE"] = string(typ)
+}
+
+// URI returns the value of the URI attribute.
+func (attrs PreloadHintAttrs) URI() string {
+	return AttributeValue(attrs["URI"]).unquote()
+}
+
+// SetURI sets the value of the URI attribute.
+func (attrs PreloadHintAttrs) SetURI(uri string) {
+	attrs["URI"] = string(EncodeQuotedString(uri))
+}
+
+// ByteRangeStart returns the value of the BYTERANGE-START attribute.
+// If the attribute does not exist, it returns 0.
+func (attrs PreloadHintAttrs) ByteRangeStart() (int64, error) {
+	value := attrs["BYTERANGE-START"]
+	if value == "" {
+		return 0, nil
+	}
+	return decimalInteger64(value)
+}
+
+// SetByteRangeStart sets the value of the BYTERANGE-START attribute.
+func (attrs PreloadHintAttrs) SetByteRangeStart(start int64) {
+	attrs["BYTERANGE-START"] = strconv.FormatInt(start, 10)
+}
+
+// ByteRangeLength returns the value of the BYTERANGE-LENGTH attribute.
+// If the attribute does not exist, it returns -1, which means that the resource
+// continues to the end.
+func (attrs PreloadHintAttrs) ByteRangeLength() (int64, error) {
+	value := attrs["BYTERANGE-LENGTH"]
+	if value == "" {
+		return -1, nil
+	}
+	return decimalInteger64(value)
+}
+
+// SetByteRangeLength sets the value of the BYTERANGE-LENGTH attribute.
+func (attrs PreloadHintAttrs) SetByteRangeLength(length int64) {
+	attrs["BYTERANGE-LENGTH"] = strconv.FormatInt(length, 10)
+}
+
+// RenditionReportAttrs represents the attributes of the EXT-X-RENDITION-REPORT tag.
+type RenditionReportAttrs Attributes
+
+// URI returns the value of the URI attribute.
+func (attrs RenditionReportAttrs) URI() string {
+	return AttributeValue(attrs["URI"]).unquote()
+}
+
+// SetURI sets the value of the URI attribute.
+func (attrs RenditionReportAttrs) SetURI(uri string) {
+	attrs["URI"] = string(EncodeQuotedString(uri))
+}
+
+// LastMSN returns the value of the LAST-MSN attribute.
+func (attrs RenditionReportAttrs) LastMSN() (int64, error) {
+	return decimalInteger64(attrs["LAST-MSN"])
+}
+
+// SetLastMSN sets the value of the LAST-MSN attribute.
+func (attrs RenditionReportAttrs) SetLastMSN(sequence int64) {
+	attrs["LAST-MSN"] = strconv.FormatInt(sequence, 10)
+}
+
+// LastPart returns the value of the LAST-PART attribute.
+// If the attribute does not exist, it returns -1.
+func (attrs RenditionReportAttrs) LastPart() (int64, error) {
+	value := attrs["LAST-PART"]
+	if value == "" {
+		return -1, nil
+	}
+	return decimalInteger64(value)
+}
+
+// SetLastPart sets the value of the LAST-PART attribute.
+// If part is negative, the attribute is removed.
+func (attrs RenditionReportAttrs) SetLastPart(part int64) {
+	if part < 0 {
+		delete(attrs, "LAST-PART")
+		return
+	}
+	attrs["LAST-PART"] = strconv.FormatInt(part, 10)
+}
@@ -50,7 +50,110 @@ func TestMediaPlaylistTags(t *testing.T) {
 	})
 }
 
+func TestMediaPlaylistTagsLowLatency(t *testing.T) {
+	t.Run("getters", func(t *testing.T) {
+		tags := MediaPlaylistTags{
+			"EXT-X-SERVER-CONTROL": []string{"CAN-BLOCK-RELOAD=YES,CAN-SKIP-UNTIL=24.0,CAN-SKIP-DATERANGES=YES,HOLD-BACK=12.0,PART-HOLD-BACK=1.0"},
+			"EXT-X-PART-INF":       []string{"PART-TARGET=0.33334"},
+			"EXT-X-SKIP":           []string{"SKIPPED-SEGMENTS=3,RECENTLY-REMOVED-DATERANGES=\"split1\tsplit2\""},
+		}
+		serverControl := tags.ServerControl()
+		require.NotNil(t, serverControl)
+		assert.True(t, serverControl.CanBlockReload())
+		canSkipUntil, err := serverControl.CanSkipUntil()
+		require.NoError(t, err)
+		assert.Equal(t, 24.0, canSkipUntil)
+		assert.True(t, serverControl.CanSkipDateRanges())
+		holdBack, err := serverControl.HoldBack()
+		require.NoError(t, err)
+		assert.Equal(t, 12.0, holdBack)
+		partHoldBack, err := serverControl.PartHoldBack()
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, partHoldBack)
+
+		partInf := tags.PartInf()
+		require.NotNil(t, partInf)
+		partTarget, err := partInf.PartTarget()
+		require.NoError(t, err)
+		assert.Equal(t, 0.33334, partTarget)
+
+		skip := tags.Skip()
+		require.NotNil(t, skip)
+		skipped, err := skip.SkippedSegments()
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), skipped)
+		assert.Equal(t, []string{"split1", "split2"}, skip.RecentlyRemovedDateRanges())
+	})
+
+	t.Run("setters", func(t *testing.T) {
+		tags := make(MediaPlaylistTags)
+		serverControl := make(ServerControlAttrs)
+		serverControl.SetCanBlockReload(true)
+		serverControl.SetCanSkipUntil(24)
+		serverControl.SetCanSkipDateRanges(true)
+		serverControl.SetHoldBack(12)
+		serverControl.SetPartHoldBack(1.5)
+		tags.SetServerControl(serverControl)
+		partInf := make(PartInfAttrs)
+		partInf.SetPartTarget(0.5)
+		tags.SetPartInf(partInf)
+		skip := make(SkipAttrs)
+		skip.SetSkippedSegments(3)
+		skip.SetRecentlyRemovedDateRanges([]string{"split1", "split2"})
+		tags.SetSkip(skip)
+		assert.Equal(t, MediaPlaylistTags{
+			"EXT-X-SERVER-CONTROL": []string{"CAN-BLOCK-RELOAD=YES,CAN-SKIP-DATERANGES=YES,CAN-SKIP-UNTIL=24,HOLD-BACK=12,PART-HOLD-BACK=1.5"},
+			"EXT-X-PART-INF":       []string{"PART-TARGET=0.5"},
+			"EXT-X-SKIP":           []string{"RECENTLY-REMOVED-DATERANGES=\"split1\tsplit2\",SKIPPED-SEGMENTS=3"},
+		}, tags)
+		tags.RemoveSkip()
+		assert.Nil(t, tags.Skip())
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		tags := MediaPlaylistTags{
+			"EXT-X-SERVER-CONTROL": []string{"CAN-BLOCK-RELOAD=YES,=invalid"},
+		}
+		assert.Nil(t, tags.ServerControl())
+		assert.Nil(t, tags.PartInf())
+		assert.Nil(t, tags.Skip())
+		canSkipUntil, err := ServerControlAttrs{}.CanSkipUntil()
+		require.NoError(t, err)
+		assert.Zero(t, canSkipUntil)
+		assert.Nil(t, SkipAttrs{"SKIPPED-SEGMENTS": "3"}.RecentlyRemovedDateRanges())
+	})
+}
+
 func TestDateRangeAttrs(t *testing.T) {
+	t.Run("Validate", func(t *testing.T) {
+		attrs := DateRangeAttrs{
+			"ID":               `"4"`,
+			"START-DATE":       `"2023-05-12T05:09:20.988Z"`,
+			"PLANNED-DURATION": "60.026",
+			"SCTE35-OUT":       "0xFC306A",
+			"X-COM-EXAMPLE":    "1",
+		}
+		require.NoError(t, attrs.Validate())
+		attrs["START-DATE"] = "2023-05-12T05:09:20.988Z"
+		assert.ErrorIs(t, attrs.Validate(), ErrInvalidAttributeValue)
+		_, err := attrs.StartDate()
+		assert.ErrorIs(t, err, ErrInvalidAttributeValue)
+
+		attrs["START-DATE"] = `""`
+		startDate, err := attrs.StartDate()
+		require.NoError(t, err)
+		assert.True(t, startDate.IsZero())
+	})
+
+	t.Run("StringInOrder", func(t *testing.T) {
+		attrs, order, err := ParseTagAttributesInOrder(`ID="4",START-DATE="2023-05-12T05:09:20.988Z",PLANNED-DURATION=60.026`)
+		require.NoError(t, err)
+		dateRange := DateRangeAttrs(attrs)
+		dateRange["CLASS"] = `"com.example.ad"`
+		assert.Equal(t, `ID="4",CLASS="com.example.ad",START-DATE="2023-05-12T05:09:20.988Z",PLANNED-DURATION=60.026`,
+			dateRange.StringInOrder(append([]string{"ID", "CLASS"}, order...)))
+	})
+
 	t.Run("Decode", func(t *testing.T) {
 		t.Run("cue_out", func(t *testing.T) {
 			attrs := DateRangeAttrs{
@@ -120,3 +223,214 @@ func TestDateRangeAttrs(t *testing.T) {
 		})
 	})
 }
+
+func TestPartAttrs(t *testing.T) {
+	t.Run("getters", func(t *testing.T) {
+		attrs := PartAttrs{
+			"DURATION":    "0.33334",
+			"URI":         `"filePart273.0.mp4"`,
+			"INDEPENDENT": "YES",
+			"BYTERANGE":   `"1000@2000"`,
+			"GAP":         "YES",
+		}
+		duration, err := attrs.Duration()
+		require.NoError(t, err)
+		assert.Equal(t, 0.33334, duration)
+		assert.Equal(t, "filePart273.0.mp4", attrs.URI())
+		assert.True(t, attrs.Independent())
+		byteRange, err := attrs.ByteRange()
+		require.NoError(t, err)
+		assert.Equal(t, &ByteRange{Length: 1000, Offset: 2000, HasOffset: true}, byteRange)
+		assert.True(t, attrs.Gap())
+	})
+
+	t.Run("setters", func(t *testing.T) {
+		attrs := make(PartAttrs)
+		attrs.SetDuration(0.33334)
+		attrs.SetURI("filePart273.0.mp4")
+		attrs.SetIndependent(true)
+		attrs.SetByteRange(ByteRange{Length: 1000})
+		attrs.SetGap(true)
+		assert.Equal(t, PartAttrs{
+			"DURATION":    "0.33334",
+			"URI":         `"filePart273.0.mp4"`,
+			"INDEPENDENT": "YES",
+			"BYTERANGE":   `"1000"`,
+			"GAP":         "YES",
+		}, attrs)
+		attrs.SetIndependent(false)
+		attrs.SetGap(false)
+		assert.Equal(t, PartAttrs{
+			"DURATION":  "0.33334",
+			"URI":       `"filePart273.0.mp4"`,
+			"BYTERANGE": `"1000"`,
+		}, attrs)
+	})
+
+	t.Run("no_byterange", func(t *testing.T) {
+		byteRange, err := PartAttrs{}.ByteRange()
+		require.NoError(t, err)
+		assert.Nil(t, byteRange)
+	})
+}
+
+func TestKeyAttrs(t *testing.T) {
+	t.Run("getters", func(t *testing.T) {
+		attrs := KeyAttrs{
+			"METHOD":    "SAMPLE-AES",
+			"URI":       `"skd://key1"`,
+			"KEYFORMAT": `"com.apple.streamingkeydelivery"`,
+		}
+		assert.Equal(t, KeyMethodSampleAES, attrs.Method())
+		assert.Equal(t, "skd://key1", attrs.URI())
+		assert.Equal(t, "com.apple.streamingkeydelivery", attrs.KeyFormat())
+		assert.Equal(t, KeyFormatIdentity, KeyAttrs{"METHOD": "AES-128"}.KeyFormat())
+	})
+
+	t.Run("setters", func(t *testing.T) {
+		attrs := make(KeyAttrs)
+		attrs.SetMethod(KeyMethodAES128)
+		attrs.SetURI("key1")
+		attrs.SetKeyFormat(KeyFormatIdentity)
+		assert.Equal(t, KeyAttrs{
+			"METHOD":    "AES-128",
+			"URI":       `"key1"`,
+			"KEYFORMAT": `"identity"`,
+		}, attrs)
+		attrs.SetIV([16]byte{0x9c, 0x7d, 15: 0x01})
+		attrs.SetKeyFormatVersions([]int{1, 2, 5})
+		assert.Equal(t, "0x9C7D0000000000000000000000000001", attrs["IV"])
+		assert.Equal(t, `"1/2/5"`, attrs["KEYFORMATVERSIONS"])
+		attrs.RemoveIV()
+		assert.NotContains(t, attrs, "IV")
+	})
+
+	t.Run("IV", func(t *testing.T) {
+		attrs := KeyAttrs{"METHOD": "AES-128", "URI": `"key1"`, "IV": "0x9c7d0000000000000000000000000001"}
+		iv, err := attrs.IV()
+		require.NoError(t, err)
+		assert.Equal(t, &[16]byte{0x9c, 0x7d, 15: 0x01}, iv)
+		iv2, err := attrs.IVForSequence(10)
+		require.NoError(t, err)
+		assert.Equal(t, *iv, iv2)
+
+		delete(attrs, "IV")
+		iv, err = attrs.IV()
+		require.NoError(t, err)
+		assert.Nil(t, iv)
+		iv2, err = attrs.IVForSequence(0x0102)
+		require.NoError(t, err)
+		assert.Equal(t, [16]byte{14: 0x01, 15: 0x02}, iv2)
+		assert.Equal(t, iv2, DefaultIV(0x0102))
+
+		for _, value := range []string{"0x01", "9c7d0000000000000000000000000001", "0x9c7d000000000000000000000000000100"} {
+			_, err := KeyAttrs{"IV": value}.IV()
+			assert.ErrorIs(t, err, ErrInvalidAttributeValue, value)
+			_, err = KeyAttrs{"IV": value}.IVForSequence(1)
+			assert.ErrorIs(t, err, ErrInvalidAttributeValue, value)
+		}
+	})
+
+	t.Run("KeyFormatVersions", func(t *testing.T) {
+		versions, err := KeyAttrs{}.KeyFormatVersions()
+		require.NoError(t, err)
+		assert.Equal(t, []int{1}, versions)
+		versions, err = KeyAttrs{"KEYFORMATVERSIONS": `"1/2/5"`}.KeyFormatVersions()
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 2, 5}, versions)
+		for _, value := range []string{"1", `"1//2"`, `"0"`, `"a"`} {
+			_, err := KeyAttrs{"KEYFORMATVERSIONS": value}.KeyFormatVersions()
+			assert.ErrorIs(t, err, ErrInvalidAttributeValue, value)
+		}
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		assert.NoError(t, KeyAttrs{"METHOD": "AES-128", "URI": `"key1"`, "IV": "0x01"}.Validate())
+		assert.ErrorIs(t, KeyAttrs{"METHOD": `"AES-128"`}.Validate(), ErrInvalidAttributeValue)
+		assert.ErrorIs(t, KeyAttrs{"METHOD": "AES-128", "URI": "key1"}.Validate(), ErrInvalidAttributeValue)
+		assert.ErrorIs(t, KeyAttrs{"URI": `"key1"`}.Validate(), ErrMissingMethod)
+	})
+}
+
+func TestMapAttrs(t *testing.T) {
+	attrs := make(MapAttrs)
+	byteRange, err := attrs.ByteRange()
+	require.NoError(t, err)
+	assert.Nil(t, byteRange)
+	attrs.SetURI("init.mp4")
+	attrs.SetByteRange(ByteRange{Length: 720, HasOffset: true})
+	assert.Equal(t, MapAttrs{"URI": `"init.mp4"`, "BYTERANGE": `"720@0"`}, attrs)
+	assert.Equal(t, "init.mp4", attrs.URI())
+	byteRange, err = attrs.ByteRange()
+	require.NoError(t, err)
+	assert.Equal(t, &ByteRange{Length: 720, HasOffset: true}, byteRange)
+}
+
+func TestPreloadHintAttrs(t *testing.T) {
+	t.Run("getters", func(t *testing.T) {
+		attrs := PreloadHintAttrs{
+			"TYPE":             "PART",
+			"URI":              `"filePart273.4.mp4"`,
+			"BYTERANGE-START":  "1000",
+			"BYTERANGE-LENGTH": "2000",
+		}
+		assert.Equal(t, PreloadHintTypePart, attrs.Type())
+		assert.Equal(t, "filePart273.4.mp4", attrs.URI())
+		start, err := attrs.ByteRangeStart()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1000), start)
+		length, err := attrs.ByteRangeLength()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2000), length)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		attrs := PreloadHintAttrs{
+			"TYPE": "MAP",
+			"URI":  `"init.mp4"`,
+		}
+		start, err := attrs.ByteRangeStart()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), start)
+		length, err := attrs.ByteRangeLength()
+		require.NoError(t, err)
+		assert.Equal(t, int64(-1), length)
+	})
+
+	t.Run("setters", func(t *testing.T) {
+		attrs := make(PreloadHintAttrs)
+		attrs.SetType(PreloadHintTypeMap)
+		attrs.SetURI("init.mp4")
+		attrs.SetByteRangeStart(1000)
+		attrs.SetByteRangeLength(2000)
+		assert.Equal(t, PreloadHintAttrs{
+			"TYPE":             "MAP",
+			"URI":              `"init.mp4"`,
+			"BYTERANGE-START":  "1000",
+			"BYTERANGE-LENGTH": "2000",
+		}, attrs)
+	})
+}
+
+func TestRenditionReportAttrs(t *testing.T) {
+	attrs := make(RenditionReportAttrs)
+	attrs.SetURI("../1M/waitForMSN.php")
+	attrs.SetLastMSN(273)
+	attrs.SetLastPart(2)
+	assert.Equal(t, RenditionReportAttrs{
+		"URI":       `"../1M/waitForMSN.php"`,
+		"LAST-MSN":  "273",
+		"LAST-PART": "2",
+	}, attrs)
+	assert.Equal(t, "../1M/waitForMSN.php", attrs.URI())
+	lastMSN, err := attrs.LastMSN()
+	require.NoError(t, err)
+	assert.Equal(t, int64(273), lastMSN)
+	lastPart, err := attrs.LastPart()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), lastPart)
+	attrs.SetLastPart(-1)
+	lastPart, err = attrs.LastPart()
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), lastPart)
+}
@@ -2,8 +2,12 @@ package m3u8
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -148,6 +152,86 @@ http://media.example.com/segment2682.ts
 http://media.example.com/segment2683.ts
 `
 
+var sampleLowLatencyInput = `#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXT-X-VERSION:6
+#EXT-X-MEDIA-SEQUENCE:271
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-PROGRAM-DATE-TIME:2019-02-14T02:14:00.106Z
+#EXTINF:4.00008,
+fileSequence271.mp4
+#EXT-X-PART:DURATION=2.00004,URI="filePart272.0.mp4",INDEPENDENT=YES
+#EXT-X-PART:DURATION=2.00004,URI="filePart272.1.mp4"
+#EXTINF:4.00008,
+fileSequence272.mp4
+#EXT-X-DISCONTINUITY
+#EXT-X-PART:DURATION=2.00004,URI="filePart273.0.mp4",INDEPENDENT=YES
+#EXT-X-PART:DURATION=2.00004,URI="filePart273.1.mp4",GAP=YES
+#EXT-X-PRELOAD-HINT:TYPE=PART,URI="filePart273.2.mp4"
+
+#EXT-X-RENDITION-REPORT:URI="../1M/waitForMSN.php",LAST-MSN=273,LAST-PART=1
+#EXT-X-RENDITION-REPORT:URI="../4M/waitForMSN.php",LAST-MSN=273,LAST-PART=1
+`
+
+var sampleLowLatencyOutput = `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:271
+#EXT-X-MAP:URI="init.mp4"
+#EXT-X-PROGRAM-DATE-TIME:2019-02-14T02:14:00.106Z
+#EXTINF:4.00008,
+fileSequence271.mp4
+#EXT-X-PART:DURATION=2.00004,INDEPENDENT=YES,URI="filePart272.0.mp4"
+#EXT-X-PART:DURATION=2.00004,URI="filePart272.1.mp4"
+#EXTINF:4.00008,
+fileSequence272.mp4
+#EXT-X-DISCONTINUITY
+#EXT-X-PART:DURATION=2.00004,INDEPENDENT=YES,URI="filePart273.0.mp4"
+#EXT-X-PART:DURATION=2.00004,GAP=YES,URI="filePart273.1.mp4"
+#EXT-X-PRELOAD-HINT:TYPE=PART,URI="filePart273.2.mp4"
+#EXT-X-RENDITION-REPORT:LAST-MSN=273,LAST-PART=1,URI="../1M/waitForMSN.php"
+#EXT-X-RENDITION-REPORT:LAST-MSN=273,LAST-PART=1,URI="../4M/waitForMSN.php"
+`
+
+var sampleServerControlInput = `#EXTM3U
+#EXT-X-SKIP:SKIPPED-SEGMENTS=2
+#EXT-X-PART-INF:PART-TARGET=0.5
+#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,CAN-SKIP-UNTIL=12,PART-HOLD-BACK=1.5
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-TARGETDURATION:2
+#EXT-X-VERSION:9
+#EXTINF:2,
+segment102.mp4
+`
+
+var sampleServerControlOutput = `#EXTM3U
+#EXT-X-VERSION:9
+#EXT-X-TARGETDURATION:2
+#EXT-X-MEDIA-SEQUENCE:100
+#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,CAN-SKIP-UNTIL=12,PART-HOLD-BACK=1.5
+#EXT-X-PART-INF:PART-TARGET=0.5
+#EXT-X-SKIP:SKIPPED-SEGMENTS=2
+#EXTINF:2,
+segment102.mp4
+`
+
+var samplePreserveOrder = `#EXTM3U
+#EXT-X-MEDIA-SEQUENCE:2680
+#EXT-X-VERSION:3
+#EXT-X-VENDOR-HEADER:foo
+#EXT-X-TARGETDURATION:5
+#EXTINF:5,
+http://media.example.com/segment2680.ts
+#EXT-X-DATERANGE:ID="100",START-DATE="2024-01-01T01:00:00.000Z",DURATION=60.000
+#EXT-X-DISCONTINUITY
+#EXT-X-PROGRAM-DATE-TIME:2024-01-01T01:00:50.000Z
+#EXT-X-PART:DURATION=2.5,URI="part2681.0.ts"
+#EXTINF:5,
+#EXT-X-PART:DURATION=2.5,URI="part2681.1.ts"
+http://media.example.com/segment2681.ts
+#EXT-X-PART:DURATION=2.5,URI="part2682.0.ts"
+`
+
 func TestDecodeMediaPlaylist(t *testing.T) {
 	t.Run("simple", func(t *testing.T) {
 		for idx, testData := range []struct {
@@ -193,4 +277,550 @@ func TestDecodeMediaPlaylist(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("server_control", func(t *testing.T) {
+		r := bytes.NewReader([]byte(sampleServerControlInput))
+		playlist, err := DecodeMediaPlaylist(r)
+		require.NoError(t, err)
+		assert.True(t, playlist.Tags.ServerControl().CanBlockReload())
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, sampleServerControlOutput, w.String())
+	})
+
+	t.Run("preserve_tag_order", func(t *testing.T) {
+		r := bytes.NewReader([]byte(samplePreserveOrder))
+		playlist, err := DecodeMediaPlaylistWithOptions(r, &DecodeOptions{PreserveTagOrder: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"EXTM3U", "EXT-X-MEDIA-SEQUENCE", "EXT-X-VERSION", "EXT-X-VENDOR-HEADER", "EXT-X-TARGETDURATION"}, playlist.TagOrder)
+		require.Len(t, playlist.Segments, 2)
+		assert.Equal(t, []string{"EXTINF"}, playlist.Segments[0].TagOrder)
+		require.NotNil(t, playlist.PartialSegment)
+		assert.Equal(t, []string{"EXT-X-PART"}, playlist.PartialSegment.TagOrder)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, samplePreserveOrder, w.String())
+
+		playlist.Tags.SetPlaylistType(MediaPlaylistTypeEvent)
+		playlist.Segments[1].Tags.Remove(TagExtXDiscontinuity)
+		playlist.Segments[1].Tags.Set(&Tag{Name: TagExtXKey, Attributes: "METHOD=NONE"})
+		w = bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, `#EXTM3U
+#EXT-X-PLAYLIST-TYPE:EVENT
+#EXT-X-MEDIA-SEQUENCE:2680
+#EXT-X-VERSION:3
+#EXT-X-VENDOR-HEADER:foo
+#EXT-X-TARGETDURATION:5
+#EXTINF:5,
+http://media.example.com/segment2680.ts
+#EXT-X-KEY:METHOD=NONE
+#EXT-X-DATERANGE:ID="100",START-DATE="2024-01-01T01:00:00.000Z",DURATION=60.000
+#EXT-X-PROGRAM-DATE-TIME:2024-01-01T01:00:50.000Z
+#EXT-X-PART:DURATION=2.5,URI="part2681.0.ts"
+#EXTINF:5,
+#EXT-X-PART:DURATION=2.5,URI="part2681.1.ts"
+http://media.example.com/segment2681.ts
+#EXT-X-PART:DURATION=2.5,URI="part2682.0.ts"
+`, w.String())
+	})
+
+	t.Run("preserve_tag_order_blank_lines_and_crlf", func(t *testing.T) {
+		input := "#EXTM3U\r\n" +
+			"#EXT-X-TARGETDURATION:5\r\n" +
+			"\r\n" +
+			"#EXTINF:5,\r\n" +
+			"segment0.ts\r\n" +
+			"\r\n"
+		playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{PreserveTagOrder: true})
+		require.NoError(t, err)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, "#EXTM3U\n"+
+			"#EXT-X-TARGETDURATION:5\n"+
+			"#EXTINF:5,\n"+
+			"segment0.ts\n", w.String())
+	})
+
+	t.Run("low_latency", func(t *testing.T) {
+		r := bytes.NewReader([]byte(sampleLowLatencyInput))
+		playlist, err := DecodeMediaPlaylist(r)
+		require.NoError(t, err)
+		require.Len(t, playlist.Segments, 2)
+		assert.Empty(t, playlist.Segments[0].Parts)
+		require.Len(t, playlist.Segments[1].Parts, 2)
+		assert.Equal(t, "filePart272.0.mp4", playlist.Segments[1].Parts[0].Attributes.URI())
+		assert.True(t, playlist.Segments[1].Parts[0].Attributes.Independent())
+		assert.False(t, playlist.Segments[1].Parts[1].Attributes.Independent())
+		require.NotNil(t, playlist.PartialSegment)
+		assert.Equal(t, int64(273), playlist.PartialSegment.Sequence)
+		assert.Equal(t, int64(1), playlist.PartialSegment.DiscontinuitySequence)
+		assert.Empty(t, playlist.PartialSegment.URI)
+		require.Len(t, playlist.PartialSegment.Parts, 2)
+		assert.True(t, playlist.PartialSegment.Parts[1].Attributes.Gap())
+		require.Len(t, playlist.PreloadHints, 1)
+		assert.Equal(t, PreloadHintTypePart, playlist.PreloadHints[0].Attributes.Type())
+		assert.Equal(t, "filePart273.2.mp4", playlist.PreloadHints[0].Attributes.URI())
+		require.Len(t, playlist.RenditionReports, 2)
+		assert.Equal(t, "../1M/waitForMSN.php", playlist.RenditionReports[0].Attributes.URI())
+		assert.Empty(t, playlist.Tags[TagExtXRenditionReport])
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, sampleLowLatencyOutput, w.String())
+	})
+
+	t.Run("low_latency_preserve_attribute_order", func(t *testing.T) {
+		r := bytes.NewReader([]byte(sampleLowLatencyInput))
+		playlist, err := DecodeMediaPlaylistWithOptions(r, &DecodeOptions{PreserveAttributeOrder: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"DURATION", "URI", "INDEPENDENT"}, playlist.Segments[1].Parts[0].AttributeOrder)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Contains(t, w.String(), "#EXT-X-PART:DURATION=2.00004,URI=\"filePart272.0.mp4\",INDEPENDENT=YES\n")
+		assert.Contains(t, w.String(), "#EXT-X-RENDITION-REPORT:URI=\"../1M/waitForMSN.php\",LAST-MSN=273,LAST-PART=1\n")
+	})
+
+	t.Run("low_latency_part_positions", func(t *testing.T) {
+		input := `#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXT-X-PART-INF:PART-TARGET=1.0
+#EXT-X-PART:DURATION=1.0,URI="part0.0.mp4"
+#EXT-X-PROGRAM-DATE-TIME:2024-01-01T00:00:00.000Z
+#EXT-X-PART:DURATION=1.0,URI="part0.1.mp4"
+#EXT-X-DATERANGE:ID="ad",START-DATE="2024-01-01T00:00:02.000Z"
+#EXT-X-PART:DURATION=1.0,URI="part0.2.mp4"
+#EXTINF:3.0,
+seg0.mp4
+#EXT-X-PART:DURATION=1.0,URI="part1.0.mp4"
+#EXT-X-PROGRAM-DATE-TIME:2024-01-01T00:00:03.000Z
+#EXT-X-PART:DURATION=1.0,URI="part1.1.mp4"
+`
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		assert.Nil(t, playlist.Segments[0].TagOrder)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+
+		playlist.Segments[0].Tags.Remove(TagExtXDateRange)
+		playlist.Segments[0].Tags.Set(&Tag{Name: TagExtXDiscontinuity})
+		w = bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, `#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXT-X-PART-INF:PART-TARGET=1.0
+#EXT-X-DISCONTINUITY
+#EXT-X-PART:DURATION=1.0,URI="part0.0.mp4"
+#EXT-X-PROGRAM-DATE-TIME:2024-01-01T00:00:00.000Z
+#EXT-X-PART:DURATION=1.0,URI="part0.1.mp4"
+#EXT-X-PART:DURATION=1.0,URI="part0.2.mp4"
+#EXTINF:3.0,
+seg0.mp4
+#EXT-X-PART:DURATION=1.0,URI="part1.0.mp4"
+#EXT-X-PROGRAM-DATE-TIME:2024-01-01T00:00:03.000Z
+#EXT-X-PART:DURATION=1.0,URI="part1.1.mp4"
+`, w.String())
+	})
+}
+
+func TestNewRenditionReports(t *testing.T) {
+	partialPlaylist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(sampleLowLatencyInput)))
+	require.NoError(t, err)
+	completePlaylist := &MediaPlaylist{
+		Tags: MediaPlaylistTags{"EXT-X-MEDIA-SEQUENCE": []string{"10"}},
+		Segments: []*Segment{
+			{URI: "segment10.mp4"},
+			{URI: "segment11.mp4", Parts: []*Part{{}, {}, {}}},
+		},
+	}
+	skippedPlaylist := &MediaPlaylist{
+		Tags: MediaPlaylistTags{
+			"EXT-X-MEDIA-SEQUENCE": []string{"10"},
+			"EXT-X-SKIP":           []string{"SKIPPED-SEGMENTS=5"},
+		},
+		Segments: []*Segment{{URI: "segment15.mp4"}},
+	}
+	reports := NewRenditionReports(map[string]*MediaPlaylist{
+		"skipped.m3u8":  skippedPlaylist,
+		"partial.m3u8":  partialPlaylist,
+		"complete.m3u8": completePlaylist,
+		"empty.m3u8":    {Tags: make(MediaPlaylistTags)},
+	})
+	assert.Equal(t, []*RenditionReport{
+		{Attributes: RenditionReportAttrs{"URI": `"complete.m3u8"`, "LAST-MSN": "11", "LAST-PART": "2"}},
+		{Attributes: RenditionReportAttrs{"URI": `"partial.m3u8"`, "LAST-MSN": "273", "LAST-PART": "1"}},
+		{Attributes: RenditionReportAttrs{"URI": `"skipped.m3u8"`, "LAST-MSN": "15"}},
+	}, reports)
+
+	t.Run("no_segments", func(t *testing.T) {
+		_, err := NewRenditionReport("empty.m3u8", &MediaPlaylist{Tags: make(MediaPlaylistTags)})
+		assert.ErrorIs(t, err, ErrNoSegments)
+		_, err = NewRenditionReport("empty.m3u8", &MediaPlaylist{
+			Tags:           make(MediaPlaylistTags),
+			PartialSegment: &Segment{Tags: make(SegmentTags)},
+		})
+		assert.ErrorIs(t, err, ErrNoSegments)
+	})
+}
+
+func TestDecodeMediaPlaylistErrors(t *testing.T) {
+	input := "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-PART:DURATION=1,URI=\"part.mp4\n"
+	_, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidAttributes)
+	var decodeErr *DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, 3, decodeErr.Line)
+	assert.Equal(t, TagExtXPart, decodeErr.Tag)
+	assert.Equal(t, "#EXT-X-PART:DURATION=1,URI=\"part.mp4", decodeErr.Raw)
+}
+
+func TestDecodeMediaPlaylistPartialResult(t *testing.T) {
+	const header = "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:4\n" +
+		"#EXTINF:4,\n" +
+		"seg1.ts\n"
+
+	t.Run("invalid_part", func(t *testing.T) {
+		input := header + "#EXT-X-PART:DURATION=1,URI=\"part.mp4\n"
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		assert.ErrorIs(t, err, ErrInvalidAttributes)
+		require.NotNil(t, playlist)
+		assert.Equal(t, "4", playlist.Tags.First(TagExtXTargetDuration).Attributes)
+		require.Len(t, playlist.Segments, 1)
+		assert.Equal(t, "seg1.ts", playlist.Segments[0].URI)
+	})
+
+	t.Run("max_segments", func(t *testing.T) {
+		input := header + "#EXTINF:4,\nseg2.ts\n"
+		playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{MaxSegments: 1})
+		assert.ErrorIs(t, err, ErrLimitExceeded)
+		require.NotNil(t, playlist)
+		require.Len(t, playlist.Segments, 1)
+		assert.Equal(t, "seg1.ts", playlist.Segments[0].URI)
+	})
+
+	t.Run("unexpected_segment_tags", func(t *testing.T) {
+		input := header + "#EXTINF:4,\n"
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		assert.ErrorIs(t, err, ErrUnexpectedSegmentTags)
+		require.NotNil(t, playlist)
+		assert.Len(t, playlist.Segments, 1)
+	})
+
+	t.Run("read_error", func(t *testing.T) {
+		readErr := errors.New("connection reset")
+		r := io.MultiReader(strings.NewReader(header), iotest.ErrReader(readErr))
+		playlist, err := DecodeMediaPlaylist(r)
+		assert.ErrorIs(t, err, readErr)
+		require.NotNil(t, playlist)
+		require.Len(t, playlist.Segments, 1)
+		assert.Equal(t, "seg1.ts", playlist.Segments[0].URI)
+	})
+}
+
+func TestDecodeMediaPlaylistComments(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"# generated by packager X\n" +
+		"#EXT-X-VERSION:3\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		"#EXTINF:6.000,\n" +
+		"# first segment\n" +
+		"seg0.ts\n" +
+		"# second segment\n" +
+		"#EXTINF:6.000,\n" +
+		"seg1.ts\n" +
+		"#EXT-X-ENDLIST\n" +
+		"# end\n"
+
+	t.Run("decode", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		assert.Equal(t, []string{" generated by packager X"}, playlist.Comments)
+		assert.Equal(t, []string{" end"}, playlist.TrailingComments)
+		assert.NotContains(t, playlist.Tags, " generated by packager X")
+		require.Len(t, playlist.Segments, 2)
+		assert.Equal(t, []string{" first segment"}, playlist.Segments[0].Comments)
+		assert.Equal(t, []string{" second segment"}, playlist.Segments[1].Comments)
+	})
+
+	t.Run("preserve_tag_order", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{PreserveTagOrder: true})
+		require.NoError(t, err)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, "#EXTM3U\n"+
+			"# generated by packager X\n"+
+			"#EXT-X-VERSION:3\n"+
+			"#EXT-X-TARGETDURATION:6\n"+
+			"#EXTINF:6.000,\n"+
+			"# first segment\n"+
+			"seg0.ts\n"+
+			"# second segment\n"+
+			"#EXTINF:6.000,\n"+
+			"seg1.ts\n"+
+			"#EXT-X-ENDLIST\n"+
+			"# end\n", w.String())
+	})
+
+	t.Run("default_order", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+
+		playlist.Tags.SetPlaylistType(MediaPlaylistTypeVOD)
+		playlist.Segments[0].Tags.SetGap(true)
+		w = bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, "#EXTM3U\n"+
+			"# generated by packager X\n"+
+			"#EXT-X-VERSION:3\n"+
+			"#EXT-X-TARGETDURATION:6\n"+
+			"#EXT-X-PLAYLIST-TYPE:VOD\n"+
+			"#EXTINF:6.000,\n"+
+			"#EXT-X-GAP\n"+
+			"# first segment\n"+
+			"seg0.ts\n"+
+			"# second segment\n"+
+			"#EXTINF:6.000,\n"+
+			"seg1.ts\n"+
+			"#EXT-X-ENDLIST\n"+
+			"# end\n", w.String())
+	})
+}
+
+func TestDecodeMediaPlaylistTrailingComments(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:4\n" +
+		"#EXT-X-PART-INF:PART-TARGET=1.000\n" +
+		"#EXTINF:4.000,\n" +
+		"seg0.mp4\n" +
+		"#EXT-X-PART:DURATION=1.000,URI=\"seg1.0.mp4\"\n" +
+		"# hint\n" +
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"seg1.1.mp4\"\n" +
+		"# reports\n" +
+		"#EXT-X-RENDITION-REPORT:URI=\"../1M/index.m3u8\",LAST-MSN=1,LAST-PART=0\n" +
+		"#EXT-X-RENDITION-REPORT:URI=\"../4M/index.m3u8\",LAST-MSN=1,LAST-PART=0\n" +
+		"# end\n"
+
+	for _, opts := range []*DecodeOptions{
+		{PreserveAttributeOrder: true},
+		{PreserveAttributeOrder: true, PreserveTagOrder: true},
+	} {
+		playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), opts)
+		require.NoError(t, err)
+		assert.Equal(t, []string{" hint", " reports", " end"}, playlist.TrailingComments)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+	}
+
+	t.Run("endlist", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-TARGETDURATION:6\n" +
+			"#EXTINF:6.000,\n" +
+			"seg0.ts\n" +
+			"# done\n" +
+			"#EXT-X-ENDLIST\n"
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+
+		report, err := NewRenditionReport("../1M/index.m3u8", playlist)
+		require.NoError(t, err)
+		playlist.RenditionReports = append(playlist.RenditionReports, report)
+		playlist.TrailingComments = append(playlist.TrailingComments, "added")
+		w = bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input+
+			"#EXT-X-RENDITION-REPORT:LAST-MSN=0,URI=\"../1M/index.m3u8\"\n"+
+			"#added\n", w.String())
+	})
+}
+
+func TestDecodeMediaPlaylistVendorTags(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		"#EXT-X-VENDOR-HEADER:1\n" +
+		"#EXTINF:6.000,\n" +
+		"seg0.ts\n" +
+		"#EXT-X-VENDOR-CUE:DURATION=6\n" +
+		"#EXTINF:6.000,\n" +
+		"seg1.ts\n" +
+		"#EXTINF:6.000,\n" +
+		"seg2.ts\n"
+
+	t.Run("preserve_tag_order", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{PreserveTagOrder: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1"}, playlist.Tags.Raw()["EXT-X-VENDOR-HEADER"])
+		assert.NotContains(t, playlist.Tags, "EXT-X-VENDOR-CUE")
+		require.Len(t, playlist.Segments, 3)
+		assert.Equal(t, []string{"DURATION=6"}, playlist.Segments[1].Tags.Raw()["EXT-X-VENDOR-CUE"])
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+	})
+
+	t.Run("default_order", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		assert.NotContains(t, playlist.Tags, "EXT-X-VENDOR-CUE")
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, "#EXTM3U\n"+
+			"#EXT-X-TARGETDURATION:6\n"+
+			"#EXT-X-VENDOR-HEADER:1\n"+
+			"#EXTINF:6.000,\n"+
+			"seg0.ts\n"+
+			"#EXTINF:6.000,\n"+
+			"#EXT-X-VENDOR-CUE:DURATION=6\n"+
+			"seg1.ts\n"+
+			"#EXTINF:6.000,\n"+
+			"seg2.ts\n", w.String())
+	})
+
+	t.Run("after_last_segment", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-TARGETDURATION:4\n" +
+			"#EXTINF:4,\n" +
+			"a.ts\n" +
+			"#EXT-X-VENDOR-END:1\n" +
+			"# end\n" +
+			"#EXT-X-ENDLIST\n" +
+			"#EXT-X-VENDOR-TRAILER:1\n"
+		for _, opts := range []*DecodeOptions{nil, {PreserveTagOrder: true}} {
+			playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), opts)
+			require.NoError(t, err)
+			assert.NotContains(t, playlist.Tags, "EXT-X-VENDOR-END")
+			assert.NotContains(t, playlist.Tags, "EXT-X-VENDOR-TRAILER")
+			assert.Equal(t, []*Tag{
+				{Name: "EXT-X-VENDOR-END", Attributes: "1"},
+				{Name: "EXT-X-VENDOR-TRAILER", Attributes: "1"},
+			}, playlist.TrailingTags)
+			w := bytes.NewBuffer(nil)
+			require.NoError(t, playlist.Encode(w))
+			assert.Equal(t, input, w.String())
+		}
+	})
+}
+
+func TestEncodeMediaPlaylistDeterministic(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		"#EXT-X-FOO\n" +
+		"#EXT-X-BAR\n" +
+		"#EXT-X-BAZ\n" +
+		"#EXTINF:6.000,\n" +
+		"seg0.ts\n"
+	for i := 0; i < 50; i++ {
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		require.Equal(t, input, w.String())
+	}
+}
+
+func TestDecodeMediaPlaylistGapAndBitrate(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		"#EXTINF:6.000,\n" +
+		"seg0.ts\n" +
+		"#EXT-X-BITRATE:1500\n" +
+		"#EXTINF:6.000,\n" +
+		"seg1.ts\n" +
+		"#EXT-X-GAP\n" +
+		"#EXTINF:6.000,\n" +
+		"seg2.ts\n" +
+		"#EXT-X-BITRATE:2000\n" +
+		"#EXTINF:6.000,\n" +
+		"seg3.ts\n"
+	playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{Mode: DecodeModeStrict})
+	require.NoError(t, err)
+	assert.NotContains(t, playlist.Tags, TagExtXGap)
+	assert.NotContains(t, playlist.Tags, TagExtXBitrate)
+	require.Len(t, playlist.Segments, 4)
+	assert.False(t, playlist.Segments[1].Tags.Gap())
+	assert.True(t, playlist.Segments[2].Tags.Gap())
+
+	_, ok := playlist.SegmentBitrate(0)
+	assert.False(t, ok)
+	for i, expected := range []int64{1500, 1500, 2000} {
+		bitrate, ok := playlist.SegmentBitrate(i + 1)
+		require.True(t, ok)
+		assert.Equal(t, expected, bitrate)
+	}
+	_, ok = playlist.Segments[2].Tags.Bitrate()
+	assert.False(t, ok)
+	_, ok = playlist.SegmentBitrate(4)
+	assert.False(t, ok)
+	_, ok = playlist.SegmentBitrate(-1)
+	assert.False(t, ok)
+
+	w := bytes.NewBuffer(nil)
+	require.NoError(t, playlist.Encode(w))
+	assert.Equal(t, "#EXTM3U\n"+
+		"#EXT-X-TARGETDURATION:6\n"+
+		"#EXTINF:6.000,\n"+
+		"seg0.ts\n"+
+		"#EXTINF:6.000,\n"+
+		"#EXT-X-BITRATE:1500\n"+
+		"seg1.ts\n"+
+		"#EXTINF:6.000,\n"+
+		"#EXT-X-GAP\n"+
+		"seg2.ts\n"+
+		"#EXTINF:6.000,\n"+
+		"#EXT-X-BITRATE:2000\n"+
+		"seg3.ts\n", w.String())
+
+	_, err = DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte("#EXTM3U\n#EXT-X-BITRATE:fast\n#EXTINF:6.000,\nseg0.ts\n")), &DecodeOptions{Mode: DecodeModeStrict})
+	assert.ErrorIs(t, err, ErrInvalidTagValue)
+}
+
+func TestMediaPlaylistSegmentBitrate(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		"#EXT-X-PART-INF:PART-TARGET=2.0\n" +
+		"#EXT-X-BITRATE:1500\n" +
+		"#EXTINF:6.000,\n" +
+		"seg0.ts\n" +
+		"#EXT-X-BYTERANGE:1000@0\n" +
+		"#EXTINF:6.000,\n" +
+		"main.ts\n" +
+		"#EXT-X-BITRATE:2000\n" +
+		"#EXT-X-BYTERANGE:1000\n" +
+		"#EXTINF:6.000,\n" +
+		"main.ts\n" +
+		"#EXTINF:6.000,\n" +
+		"seg3.ts\n" +
+		"#EXT-X-PART:DURATION=2.0,URI=\"part4.0.ts\"\n"
+	playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+	require.Len(t, playlist.Segments, 4)
+	require.NotNil(t, playlist.PartialSegment)
+
+	bitrate, ok := playlist.SegmentBitrate(0)
+	require.True(t, ok)
+	assert.Equal(t, int64(1500), bitrate)
+	_, ok = playlist.SegmentBitrate(1)
+	assert.False(t, ok)
+	_, ok = playlist.SegmentBitrate(2)
+	assert.False(t, ok)
+	bitrate, ok = playlist.SegmentBitrate(3)
+	require.True(t, ok)
+	assert.Equal(t, int64(2000), bitrate)
+	bitrate, ok = playlist.SegmentBitrate(4)
+	require.True(t, ok)
+	assert.Equal(t, int64(2000), bitrate)
+	_, ok = playlist.SegmentBitrate(5)
+	assert.False(t, ok)
+
+	playlist.Segments[2].Tags.Set(&Tag{Name: TagExtXBitrate, Attributes: "+2000"})
+	_, ok = playlist.SegmentBitrate(3)
+	assert.False(t, ok)
 }
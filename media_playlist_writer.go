@@ -0,0 +1,123 @@
+package m3u8
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrHeaderNotWritten is returned when MediaPlaylistWriter writes a line before the header.
+	ErrHeaderNotWritten = errors.New("header not written")
+
+	// ErrHeaderWritten is returned when MediaPlaylistWriter writes the header twice.
+	ErrHeaderWritten = errors.New("header already written")
+)
+
+// MediaPlaylistWriter writes a media playlist segment by segment.
+// Calling WriteHeader, WriteSegment for each segment, WriteTag for each trailing tag,
+// WritePreloadHint for each hint,
+// WriteEndList and WriteRenditionReport for each report in this order
+// produces the same output as MediaPlaylist.Encode.
+type MediaPlaylistWriter struct {
+	w             io.Writer
+	registry      *TagRegistry
+	headerWritten bool
+}
+
+// NewMediaPlaylistWriter creates a MediaPlaylistWriter which writes to io.Writer.
+// It sorts the tags by the orders in DefaultTagRegistry.
+func NewMediaPlaylistWriter(w io.Writer) *MediaPlaylistWriter {
+	return NewMediaPlaylistWriterWithRegistry(w, nil)
+}
+
+// NewMediaPlaylistWriterWithRegistry creates a MediaPlaylistWriter which sorts the tags
+// by the orders in the registry. If registry is nil, DefaultTagRegistry is used.
+func NewMediaPlaylistWriterWithRegistry(w io.Writer, registry *TagRegistry) *MediaPlaylistWriter {
+	return &MediaPlaylistWriter{w: w, registry: tagRegistryOrDefault(registry)}
+}
+
+// WriteHeader writes the tags of the media playlist.
+// If order is not nil, the tags are written in this order. See Tags#ListInOrder.
+func (pw *MediaPlaylistWriter) WriteHeader(tags MediaPlaylistTags, order []string) error {
+	return pw.WriteHeaderWithComments(tags, order, nil)
+}
+
+// WriteHeaderWithComments writes the tags and the comments of the media playlist.
+// The comments are placed at the CommentMarker entries of order, or right after EXTM3U
+// if order is nil.
+func (pw *MediaPlaylistWriter) WriteHeaderWithComments(tags MediaPlaylistTags, order []string, comments []string) error {
+	return pw.writeHeader(tags, order, nil, comments)
+}
+
+// writeHeader writes the header like WriteHeaderWithComments.
+// If order is nil, the comments are placed at the CommentMarker entries of positions. See Tags#listAnchored.
+func (pw *MediaPlaylistWriter) writeHeader(tags MediaPlaylistTags, order, positions []string, comments []string) error {
+	if pw.headerWritten {
+		return ErrHeaderWritten
+	}
+	pw.headerWritten = true
+	raw := tags.Raw().withComments(comments)
+	var list []*Tag
+	if order != nil {
+		list = raw.listInOrder(pw.registry, order)
+	} else if positions != nil {
+		list = raw.listAnchored(pw.registry, positions, isComment)
+	} else {
+		list = raw.list(pw.registry)
+	}
+	return encodeTagList(pw.w, list)
+}
+
+// WriteSegment writes a segment with its tags and partial segments.
+// A segment without URI is written as a partial segment in progress.
+func (pw *MediaPlaylistWriter) WriteSegment(segment *Segment) error {
+	if !pw.headerWritten {
+		return ErrHeaderNotWritten
+	}
+	return segment.encode(pw.w, pw.registry)
+}
+
+// WriteTag writes a tag which follows the last segment, such as a vendor cue marker.
+func (pw *MediaPlaylistWriter) WriteTag(tag *Tag) error {
+	if !pw.headerWritten {
+		return ErrHeaderNotWritten
+	}
+	return tag.Encode(pw.w)
+}
+
+// WritePreloadHint writes an EXT-X-PRELOAD-HINT tag.
+func (pw *MediaPlaylistWriter) WritePreloadHint(hint *PreloadHint) error {
+	if !pw.headerWritten {
+		return ErrHeaderNotWritten
+	}
+	_, err := fmt.Fprintf(pw.w, "#%s:%s\n", TagExtXPreloadHint, encodeAttributes(Attributes(hint.Attributes), hint.AttributeOrder))
+	return err
+}
+
+// WriteComment writes a comment line.
+// The comment excludes the leading "#".
+func (pw *MediaPlaylistWriter) WriteComment(comment string) error {
+	if !pw.headerWritten {
+		return ErrHeaderNotWritten
+	}
+	return encodeComments(pw.w, []string{comment})
+}
+
+// WriteEndList writes an EXT-X-ENDLIST tag.
+func (pw *MediaPlaylistWriter) WriteEndList() error {
+	if !pw.headerWritten {
+		return ErrHeaderNotWritten
+	}
+	_, err := pw.w.Write([]byte("#" + TagExtXEndlist + "\n"))
+	return err
+}
+
+// WriteRenditionReport writes an EXT-X-RENDITION-REPORT tag.
+func (pw *MediaPlaylistWriter) WriteRenditionReport(report *RenditionReport) error {
+	if !pw.headerWritten {
+		return ErrHeaderNotWritten
+	}
+	_, err := fmt.Fprintf(pw.w, "#%s:%s\n", TagExtXRenditionReport, encodeAttributes(Attributes(report.Attributes), report.AttributeOrder))
+	return err
+}
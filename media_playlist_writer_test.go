@@ -0,0 +1,114 @@
+package m3u8
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaPlaylistWriter(t *testing.T) {
+	t.Run("same_as_encode", func(t *testing.T) {
+		for _, input := range []string{
+			sampleMedia01Input,
+			sampleMedia03Input,
+			sampleLowLatencyInput,
+			samplePreserveOrder,
+		} {
+			opts := &DecodeOptions{PreserveTagOrder: true}
+			playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), opts)
+			require.NoError(t, err)
+			expected := bytes.NewBuffer(nil)
+			require.NoError(t, playlist.Encode(expected))
+
+			reader, err := NewMediaPlaylistReader(bytes.NewReader([]byte(input)), opts)
+			require.NoError(t, err)
+			actual := bytes.NewBuffer(nil)
+			pw := NewMediaPlaylistWriter(actual)
+			require.NoError(t, pw.WriteHeader(reader.Tags(), reader.Playlist().TagOrder))
+			for {
+				segment, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				require.NoError(t, pw.WriteSegment(segment))
+			}
+			trailer := reader.Playlist()
+			if trailer.PartialSegment != nil {
+				require.NoError(t, pw.WriteSegment(trailer.PartialSegment))
+			}
+			for _, hint := range trailer.PreloadHints {
+				require.NoError(t, pw.WritePreloadHint(hint))
+			}
+			if trailer.EndList {
+				require.NoError(t, pw.WriteEndList())
+			}
+			for _, report := range trailer.RenditionReports {
+				require.NoError(t, pw.WriteRenditionReport(report))
+			}
+			assert.Equal(t, expected.String(), actual.String())
+		}
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		tags := make(MediaPlaylistTags)
+		tags.Raw().Set(&Tag{Name: TagExtM3U})
+		tags.Set(&Tag{Name: TagExtXVersion, Attributes: "3"})
+		tags.SetTargetDuration(6)
+		tags.SetPlaylistType(MediaPlaylistTypeVOD)
+
+		w := bytes.NewBuffer(nil)
+		pw := NewMediaPlaylistWriter(w)
+		require.NoError(t, pw.WriteHeader(tags, nil))
+		for _, uri := range []string{"seg0.ts", "seg1.ts"} {
+			segmentTags := make(SegmentTags)
+			segmentTags.SetExtInfValue(6, 64)
+			require.NoError(t, pw.WriteSegment(&Segment{Tags: segmentTags, URI: uri}))
+		}
+		require.NoError(t, pw.WriteEndList())
+		assert.Equal(t, "#EXTM3U\n"+
+			"#EXT-X-VERSION:3\n"+
+			"#EXT-X-TARGETDURATION:6\n"+
+			"#EXT-X-PLAYLIST-TYPE:VOD\n"+
+			"#EXTINF:6,\n"+
+			"seg0.ts\n"+
+			"#EXTINF:6,\n"+
+			"seg1.ts\n"+
+			"#EXT-X-ENDLIST\n", w.String())
+	})
+
+	t.Run("comments", func(t *testing.T) {
+		tags := make(MediaPlaylistTags)
+		tags.Raw().Set(&Tag{Name: TagExtM3U})
+		tags.SetTargetDuration(6)
+
+		w := bytes.NewBuffer(nil)
+		pw := NewMediaPlaylistWriter(w)
+		require.NoError(t, pw.WriteHeaderWithComments(tags, nil, []string{" generated from database"}))
+		segmentTags := make(SegmentTags)
+		segmentTags.SetExtInfValue(6, 64)
+		require.NoError(t, pw.WriteSegment(&Segment{Tags: segmentTags, URI: "seg0.ts", Comments: []string{" first segment"}}))
+		require.NoError(t, pw.WriteEndList())
+		require.NoError(t, pw.WriteComment(" end"))
+		assert.Equal(t, "#EXTM3U\n"+
+			"# generated from database\n"+
+			"#EXT-X-TARGETDURATION:6\n"+
+			"# first segment\n"+
+			"#EXTINF:6,\n"+
+			"seg0.ts\n"+
+			"#EXT-X-ENDLIST\n"+
+			"# end\n", w.String())
+	})
+
+	t.Run("order", func(t *testing.T) {
+		pw := NewMediaPlaylistWriter(io.Discard)
+		assert.ErrorIs(t, pw.WriteSegment(&Segment{URI: "seg0.ts"}), ErrHeaderNotWritten)
+		assert.ErrorIs(t, pw.WriteEndList(), ErrHeaderNotWritten)
+		assert.ErrorIs(t, pw.WriteComment(" comment"), ErrHeaderNotWritten)
+		require.NoError(t, pw.WriteHeader(make(MediaPlaylistTags), nil))
+		assert.ErrorIs(t, pw.WriteHeader(make(MediaPlaylistTags), nil), ErrHeaderWritten)
+	})
+}
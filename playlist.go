@@ -1,8 +1,8 @@
 package m3u8
 
 import (
-	"bufio"
 	"bytes"
+	"errors"
 	"io"
 )
 
@@ -32,37 +32,87 @@ type Playlist interface {
 	Media() *MediaPlaylist
 }
 
-// DecodePlaylist detects the type of playlist and decodes it from io.Reader.
-func DecodePlaylist(r io.Reader) (Playlist, error) {
-	data, err := io.ReadAll(r)
-	if err != nil {
-		return nil, err
-	}
-	br := bytes.NewReader(data)
+// ErrAmbiguousPlaylistType is returned when the playlist contains no tag
+// which determines whether it is a master playlist or a media playlist.
+var ErrAmbiguousPlaylistType = errors.New("ambiguous playlist type")
+
+// DetectPlaylistType detects the type of playlist from io.Reader.
+// The type is determined by the first tag which appears only in master playlists,
+// such as EXT-X-STREAM-INF and EXT-X-MEDIA, or only in media playlists,
+// such as EXTINF and EXT-X-TARGETDURATION. See TagRegistry.
+// It stops reading shortly after the line which determines the type.
+// If there is no such line, it returns ErrAmbiguousPlaylistType.
+func DetectPlaylistType(r io.Reader) (PlaylistType, error) {
+	typ, _, err := detectPlaylistType(r, &DecodeOptions{})
+	return typ, err
+}
 
-	var masterPlaylistTagCount int
-	var mediaPlaylistTagCount int
-	scanner := bufio.NewScanner(br)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
+// detectPlaylistType also reports whether the playlist has no tags and URIs
+// other than the EXTM3U tag when the type is ambiguous.
+func detectPlaylistType(r io.Reader, opts *DecodeOptions) (PlaylistType, bool, error) {
+	registry := opts.tagRegistry()
+	lexer := NewLexer(r, opts)
+	empty := true
+	for {
+		token, err := lexer.Next()
+		if err == io.EOF {
+			return "", empty, ErrAmbiguousPlaylistType
+		} else if err != nil {
+			return "", false, err
+		}
+		switch token.Type {
+		case TokenTag:
+			empty = false
+		case TokenURI:
+			empty = false
+			continue
+		default:
 			continue
 		}
-		tagName := TagName(line)
-		if isMasterPlaylistTag(tagName) {
-			masterPlaylistTagCount++
-		} else if isMediaPlaylistTag(tagName) || IsSegmentTagName(tagName) {
-			mediaPlaylistTagCount++
+		switch registry.Definition(token.Name).Scope {
+		case TagScopeMasterPlaylist:
+			return PlaylistTypeMaster, false, nil
+		case TagScopeMediaPlaylist, TagScopeSegment:
+			return PlaylistTypeMedia, false, nil
 		}
 	}
-	if err := scanner.Err(); err != nil {
+}
+
+// DecodePlaylist detects the type of playlist and decodes it from io.Reader.
+// See DetectPlaylistType for how the type is detected.
+func DecodePlaylist(r io.Reader) (Playlist, error) {
+	return DecodePlaylistWithOptions(r, nil)
+}
+
+// DecodePlaylistWithOptions detects the type of playlist and decodes it from io.Reader
+// with the options.
+// Only the beginning of the input which is read to detect the type is buffered.
+// A playlist which has no tags other than the EXTM3U tag is decoded as an empty master playlist.
+// Otherwise, if the type cannot be detected, it returns ErrAmbiguousPlaylistType.
+func DecodePlaylistWithOptions(r io.Reader, opts *DecodeOptions) (Playlist, error) {
+	d := newDecodeState(opts)
+	recorder := &recordingReader{r: r}
+	typ, empty, err := detectPlaylistType(recorder, d.opts)
+	if err == ErrAmbiguousPlaylistType && empty {
+		typ = PlaylistTypeMaster
+	} else if err != nil {
 		return nil, err
 	}
-
-	br.Seek(0, io.SeekStart)
-	if masterPlaylistTagCount >= mediaPlaylistTagCount {
-		return DecodeMasterPlaylist(br)
-	} else {
-		return DecodeMediaPlaylist(br)
+	r = io.MultiReader(bytes.NewReader(recorder.buf.Bytes()), r)
+	if typ == PlaylistTypeMaster {
+		return DecodeMasterPlaylistWithOptions(r, opts)
 	}
+	return DecodeMediaPlaylistWithOptions(r, opts)
+}
+
+// recordingReader is an io.Reader which keeps the data read from the underlying reader.
+type recordingReader struct {
+	r   io.Reader
+	buf bytes.Buffer
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.buf.Write(p[:n])
+	return n, err
 }
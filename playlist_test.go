@@ -2,7 +2,10 @@ package m3u8
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -33,3 +36,73 @@ func TestDecodePlaylist(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodePlaylistWithOptions(t *testing.T) {
+	r := bytes.NewReader([]byte(sampleMedia01Input))
+	playlist, err := DecodePlaylistWithOptions(r, &DecodeOptions{PreserveTagOrder: true})
+	require.NoError(t, err)
+	require.Equal(t, PlaylistTypeMedia, playlist.Type())
+	assert.Equal(t, []string{"EXTM3U", "EXT-X-VERSION", "EXT-X-MEDIA-SEQUENCE", "EXT-X-TARGETDURATION"}, playlist.Media().TagOrder)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("must not be read")
+}
+
+func TestDetectPlaylistType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected PlaylistType
+	}{
+		{
+			name:     "stream",
+			input:    "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-STREAM-INF:BANDWIDTH=1280000\nlow.m3u8\n",
+			expected: PlaylistTypeMaster,
+		},
+		{
+			name:     "session_data_only",
+			input:    "#EXTM3U\n#EXT-X-SESSION-DATA:DATA-ID=\"com.example\",VALUE=\"a\"\n",
+			expected: PlaylistTypeMaster,
+		},
+		{
+			name:     "target_duration_only",
+			input:    "#EXTM3U\n#EXT-X-TARGETDURATION:6\n",
+			expected: PlaylistTypeMedia,
+		},
+		{
+			name:     "segment",
+			input:    "#EXTM3U\n# comment\n#EXT-X-INDEPENDENT-SEGMENTS\n#EXTINF:6.000,\nseg0.ts\n",
+			expected: PlaylistTypeMedia,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			typ, err := DetectPlaylistType(io.MultiReader(strings.NewReader(tc.input), failingReader{}))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, typ)
+
+			playlist, err := DecodePlaylist(strings.NewReader(tc.input))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, playlist.Type())
+		})
+	}
+
+	t.Run("ambiguous", func(t *testing.T) {
+		input := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-INDEPENDENT-SEGMENTS\n"
+		_, err := DetectPlaylistType(strings.NewReader(input))
+		assert.ErrorIs(t, err, ErrAmbiguousPlaylistType)
+		_, err = DecodePlaylist(strings.NewReader(input))
+		assert.ErrorIs(t, err, ErrAmbiguousPlaylistType)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := DetectPlaylistType(strings.NewReader("#EXTM3U\n"))
+		assert.ErrorIs(t, err, ErrAmbiguousPlaylistType)
+		playlist, err := DecodePlaylist(strings.NewReader("#EXTM3U\n"))
+		require.NoError(t, err)
+		assert.Equal(t, PlaylistTypeMaster, playlist.Type())
+	})
+}
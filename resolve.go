@@ -0,0 +1,157 @@
+package m3u8
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownByteRangeOffset is returned when an EXT-X-BYTERANGE tag without an offset
+// does not follow a sub-range of the same resource.
+var ErrUnknownByteRangeOffset = errors.New("unknown byte range offset")
+
+// ResolvedSegment represents a media segment along with the state
+// which is inherited from the preceding segments.
+type ResolvedSegment struct {
+	// Segment is the media segment.
+	Segment *Segment
+
+	// Keys is a list of the attributes of the EXT-X-KEY tags which apply to the segment,
+	// one for each KEYFORMAT in the order in which they first appear.
+	// If the segment is not encrypted, it is nil.
+	Keys []KeyAttrs
+
+	// Map is the attributes of the EXT-X-MAP tag which applies to the segment.
+	// If there is no media initialization section, it is nil.
+	Map MapAttrs
+
+	// MapKeys is a list of the attributes of the EXT-X-KEY tags which applied
+	// when the EXT-X-MAP tag appeared, in the same form as Keys.
+	// If the media initialization section is not encrypted, it is nil.
+	MapKeys []KeyAttrs
+
+	// ByteRange is the sub-range of the resource with the absolute offset.
+	// If the segment is the whole resource, it is nil.
+	ByteRange *ByteRange
+
+	// Bitrate is the value of the EXT-X-BITRATE tag which applies to the segment
+	// in kilobits per second. If there is no such tag, it is 0.
+	// The tag does not apply to a segment with an EXT-X-BYTERANGE tag. See MediaPlaylist#SegmentBitrate.
+	Bitrate int64
+}
+
+// Resolve applies the state defined by the preceding tags to each segment,
+// including the partial segment at the end.
+// EXT-X-KEY, EXT-X-MAP and EXT-X-BITRATE tags apply to the segments until they are replaced,
+// except that EXT-X-BITRATE does not apply to the segments with EXT-X-BYTERANGE,
+// and an EXT-X-BYTERANGE tag without an offset continues from the previous sub-range.
+// An EXT-X-KEY tag replaces the key with the same KEYFORMAT, and one with METHOD=NONE
+// removes all the keys.
+// In a delta update, the tags in the skipped segments are not taken into account.
+func (playlist *MediaPlaylist) Resolve() ([]*ResolvedSegment, error) {
+	segments := playlist.allSegments()
+	resolved := make([]*ResolvedSegment, 0, len(segments))
+	var keys []KeyAttrs
+	var initSection MapAttrs
+	var mapKeys []KeyAttrs
+	var bitrates bitrateState
+	var prev *ResolvedSegment
+	for _, segment := range segments {
+		if values, ok := segment.Tags[TagExtXMap]; ok && len(values) != 0 {
+			attrs, err := ParseTagAttributes(values[0])
+			if err != nil {
+				return nil, fmt.Errorf("segment %d: %w", segment.Sequence, err)
+			}
+			initSection = MapAttrs(attrs)
+			mapKeys, err = resolveKeys(keys, segment.Tags[TagExtXKey][:segment.keysBeforeMap()])
+			if err != nil {
+				return nil, fmt.Errorf("segment %d: %w", segment.Sequence, err)
+			}
+		}
+		if values, ok := segment.Tags[TagExtXKey]; ok {
+			var err error
+			keys, err = resolveKeys(keys, values)
+			if err != nil {
+				return nil, fmt.Errorf("segment %d: %w", segment.Sequence, err)
+			}
+		}
+		bitrate, _, err := bitrates.next(segment)
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %w", segment.Sequence, err)
+		}
+		byteRange, err := segment.Tags.ByteRange()
+		if err != nil {
+			return nil, fmt.Errorf("segment %d: %w", segment.Sequence, err)
+		}
+		if byteRange != nil && !byteRange.HasOffset {
+			if prev == nil || prev.ByteRange == nil || prev.Segment.URI != segment.URI {
+				return nil, fmt.Errorf("segment %d: %w", segment.Sequence, ErrUnknownByteRangeOffset)
+			}
+			byteRange.Offset = prev.ByteRange.Offset + prev.ByteRange.Length
+			byteRange.HasOffset = true
+		}
+		prev = &ResolvedSegment{
+			Segment:   segment,
+			Keys:      keys,
+			Map:       initSection,
+			MapKeys:   mapKeys,
+			ByteRange: byteRange,
+			Bitrate:   bitrate,
+		}
+		resolved = append(resolved, prev)
+	}
+	return resolved, nil
+}
+
+// hasKeyAndMap reports whether the segment has both EXT-X-KEY and EXT-X-MAP tags.
+func (segment *Segment) hasKeyAndMap() bool {
+	_, hasKey := segment.Tags[TagExtXKey]
+	_, hasMap := segment.Tags[TagExtXMap]
+	return hasKey && hasMap
+}
+
+// keysBeforeMap returns the number of the EXT-X-KEY tags of the segment which precede its EXT-X-MAP tag.
+// If the order of the tags is unknown, all of them are regarded as preceding it,
+// in the same way as Encode writes them.
+func (segment *Segment) keysBeforeMap() int {
+	order := segment.TagOrder
+	if order == nil {
+		order = segment.positions
+	}
+	n := 0
+	for _, name := range order {
+		if name == TagExtXMap && n <= len(segment.Tags[TagExtXKey]) {
+			return n
+		} else if name == TagExtXKey {
+			n++
+		}
+	}
+	return len(segment.Tags[TagExtXKey])
+}
+
+// resolveKeys returns a new list of keys updated by the EXT-X-KEY tags.
+func resolveKeys(keys []KeyAttrs, values []string) ([]KeyAttrs, error) {
+	updated := append([]KeyAttrs(nil), keys...)
+	for _, value := range values {
+		attrs, err := ParseTagAttributes(value)
+		if err != nil {
+			return nil, err
+		}
+		key := KeyAttrs(attrs)
+		if key.Method() == KeyMethodNone {
+			updated = nil
+			continue
+		}
+		replaced := false
+		for i := range updated {
+			if updated[i].KeyFormat() == key.KeyFormat() {
+				updated[i] = key
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			updated = append(updated, key)
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,130 @@
+package m3u8
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		"#EXT-X-MEDIA-SEQUENCE:10\n" +
+		"#EXT-X-MAP:URI=\"init.mp4\",BYTERANGE=\"720@0\"\n" +
+		"#EXT-X-BITRATE:1500\n" +
+		"#EXTINF:6.000,\n" +
+		"#EXT-X-BYTERANGE:1000@720\n" +
+		"main.mp4\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key1\"\n" +
+		"#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"skd://key1\",KEYFORMAT=\"com.apple.streamingkeydelivery\"\n" +
+		"#EXTINF:6.000,\n" +
+		"#EXT-X-BYTERANGE:2000\n" +
+		"main.mp4\n" +
+		"#EXT-X-KEY:METHOD=AES-128,URI=\"key2\"\n" +
+		"#EXT-X-BITRATE:2000\n" +
+		"#EXTINF:6.000,\n" +
+		"#EXT-X-BYTERANGE:3000\n" +
+		"main.mp4\n" +
+		"#EXT-X-KEY:METHOD=NONE\n" +
+		"#EXT-X-MAP:URI=\"init2.mp4\"\n" +
+		"#EXTINF:6.000,\n" +
+		"other.mp4\n" +
+		"#EXT-X-PART:DURATION=1.0,URI=\"part.mp4\"\n"
+	playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+	require.NoError(t, err)
+	resolved, err := playlist.Resolve()
+	require.NoError(t, err)
+	require.Len(t, resolved, 5)
+
+	assert.Same(t, playlist.Segments[0], resolved[0].Segment)
+	assert.Nil(t, resolved[0].Keys)
+	assert.Equal(t, "init.mp4", resolved[0].Map.URI())
+	assert.Equal(t, &ByteRange{Length: 1000, Offset: 720, HasOffset: true}, resolved[0].ByteRange)
+	assert.Zero(t, resolved[0].Bitrate)
+
+	require.Len(t, resolved[1].Keys, 2)
+	assert.Equal(t, "key1", resolved[1].Keys[0].URI())
+	assert.Equal(t, KeyFormatIdentity, resolved[1].Keys[0].KeyFormat())
+	assert.Equal(t, KeyMethodSampleAES, resolved[1].Keys[1].Method())
+	assert.Equal(t, "init.mp4", resolved[1].Map.URI())
+	assert.Nil(t, resolved[1].MapKeys)
+	assert.Equal(t, &ByteRange{Length: 2000, Offset: 1720, HasOffset: true}, resolved[1].ByteRange)
+	assert.Zero(t, resolved[1].Bitrate)
+
+	require.Len(t, resolved[2].Keys, 2)
+	assert.Equal(t, "key2", resolved[2].Keys[0].URI())
+	assert.Equal(t, "skd://key1", resolved[2].Keys[1].URI())
+	assert.Equal(t, "key1", resolved[1].Keys[0].URI())
+	assert.Equal(t, &ByteRange{Length: 3000, Offset: 3720, HasOffset: true}, resolved[2].ByteRange)
+	assert.Zero(t, resolved[2].Bitrate)
+
+	assert.Nil(t, resolved[3].Keys)
+	assert.Equal(t, "init2.mp4", resolved[3].Map.URI())
+	assert.Nil(t, resolved[3].MapKeys)
+	assert.Nil(t, resolved[3].ByteRange)
+	assert.Equal(t, int64(2000), resolved[3].Bitrate)
+
+	assert.Same(t, playlist.PartialSegment, resolved[4].Segment)
+	assert.Equal(t, "init2.mp4", resolved[4].Map.URI())
+	assert.Equal(t, int64(2000), resolved[4].Bitrate)
+
+	t.Run("map keys", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-KEY:METHOD=AES-128,URI=\"key1\"\n" +
+			"#EXT-X-MAP:URI=\"init1.mp4\"\n" +
+			"#EXTINF:6.000,\n" +
+			"seg0.mp4\n" +
+			"#EXT-X-MAP:URI=\"init2.mp4\"\n" +
+			"#EXT-X-KEY:METHOD=AES-128,URI=\"key2\"\n" +
+			"#EXTINF:6.000,\n" +
+			"seg1.mp4\n" +
+			"#EXTINF:6.000,\n" +
+			"seg2.mp4\n"
+		for _, opts := range []*DecodeOptions{nil, {PreserveTagOrder: true}} {
+			playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), opts)
+			require.NoError(t, err)
+			resolved, err := playlist.Resolve()
+			require.NoError(t, err)
+			require.Len(t, resolved, 3)
+			require.Len(t, resolved[0].MapKeys, 1)
+			assert.Equal(t, "key1", resolved[0].MapKeys[0].URI())
+			for _, segment := range resolved[1:] {
+				assert.Equal(t, "init2.mp4", segment.Map.URI())
+				require.Len(t, segment.MapKeys, 1)
+				assert.Equal(t, "key1", segment.MapKeys[0].URI())
+				assert.Equal(t, "key2", segment.Keys[0].URI())
+			}
+		}
+	})
+
+	t.Run("unknown byte range offset", func(t *testing.T) {
+		inputs := []string{
+			"#EXTM3U\n#EXTINF:6.000,\n#EXT-X-BYTERANGE:1000\nmain.mp4\n",
+			"#EXTM3U\n#EXTINF:6.000,\nmain.mp4\n#EXTINF:6.000,\n#EXT-X-BYTERANGE:1000\nmain.mp4\n",
+			"#EXTM3U\n#EXTINF:6.000,\n#EXT-X-BYTERANGE:1000@0\nmain.mp4\n#EXTINF:6.000,\n#EXT-X-BYTERANGE:1000\nother.mp4\n",
+		}
+		for _, input := range inputs {
+			playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			_, err = playlist.Resolve()
+			assert.ErrorIs(t, err, ErrUnknownByteRangeOffset, input)
+		}
+	})
+
+	t.Run("invalid tags", func(t *testing.T) {
+		inputs := []string{
+			"#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key\n#EXTINF:6.000,\nmain.mp4\n",
+			"#EXTM3U\n#EXT-X-MAP:URI=\"init.mp4\n#EXTINF:6.000,\nmain.mp4\n",
+			"#EXTM3U\n#EXT-X-BITRATE:fast\n#EXTINF:6.000,\nmain.mp4\n",
+			"#EXTM3U\n#EXT-X-BYTERANGE:-1\n#EXTINF:6.000,\nmain.mp4\n",
+		}
+		for _, input := range inputs {
+			playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			_, err = playlist.Resolve()
+			assert.Error(t, err, input)
+		}
+	})
+}
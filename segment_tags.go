@@ -1,6 +1,7 @@
 package m3u8
 
 import (
+	"errors"
 	"strconv"
 	"strings"
 	"time"
@@ -92,3 +93,146 @@ func (tags SegmentTags) DateRange() []DateRangeAttrs {
 	}
 	return list
 }
+
+// ByteRange returns the value of the EXT-X-BYTERANGE tag.
+// If the tag does not exist, it returns nil.
+func (tags SegmentTags) ByteRange() (*ByteRange, error) {
+	values, ok := tags[TagExtXByteRange]
+	if !ok || len(values) == 0 {
+		return nil, nil
+	}
+	byteRange, err := ParseByteRange(values[0])
+	if err != nil {
+		return nil, err
+	}
+	return &byteRange, nil
+}
+
+// SetByteRange sets the value of the EXT-X-BYTERANGE tag.
+func (tags SegmentTags) SetByteRange(byteRange ByteRange) {
+	tags[TagExtXByteRange] = []string{byteRange.String()}
+}
+
+// Keys returns the attributes list of the EXT-X-KEY tags.
+// Invalid tags are skipped.
+func (tags SegmentTags) Keys() []KeyAttrs {
+	return parseKeys(tags[TagExtXKey])
+}
+
+// SetKeys sets the attributes list of the EXT-X-KEY tags.
+// If keys is empty, the tags are removed.
+func (tags SegmentTags) SetKeys(keys []KeyAttrs) {
+	if len(keys) == 0 {
+		delete(tags, TagExtXKey)
+		return
+	}
+	tags[TagExtXKey] = encodeKeys(keys)
+}
+
+// Map returns the attributes of the EXT-X-MAP tag.
+// If the tag does not exist or is invalid, it returns nil.
+func (tags SegmentTags) Map() MapAttrs {
+	values, ok := tags[TagExtXMap]
+	if !ok || len(values) == 0 {
+		return nil
+	}
+	attrs, err := ParseTagAttributes(values[0])
+	if err != nil {
+		return nil
+	}
+	return MapAttrs(attrs)
+}
+
+// SetMap sets the attributes of the EXT-X-MAP tag.
+func (tags SegmentTags) SetMap(attrs MapAttrs) {
+	tags[TagExtXMap] = []string{Attributes(attrs).String()}
+}
+
+// Gap reports whether the segment has the EXT-X-GAP tag.
+func (tags SegmentTags) Gap() bool {
+	_, ok := tags[TagExtXGap]
+	return ok
+}
+
+// SetGap adds the EXT-X-GAP tag if gap is true, otherwise removes it.
+func (tags SegmentTags) SetGap(gap bool) {
+	if gap {
+		tags[TagExtXGap] = []string{""}
+	} else {
+		delete(tags, TagExtXGap)
+	}
+}
+
+// Bitrate returns the value of the EXT-X-BITRATE tag in kilobits per second.
+// It returns false if the segment does not have a valid EXT-X-BITRATE tag.
+// Note that the tag also applies to the following segments. See MediaPlaylist#SegmentBitrate.
+func (tags SegmentTags) Bitrate() (int64, bool) {
+	bitrate, ok, err := tags.bitrate()
+	return bitrate, ok && err == nil
+}
+
+// bitrate returns the value of the EXT-X-BITRATE tag, or an error if it is not a decimal-integer.
+func (tags SegmentTags) bitrate() (int64, bool, error) {
+	values, ok := tags[TagExtXBitrate]
+	if !ok || len(values) == 0 {
+		return 0, false, nil
+	}
+	bitrate, err := decimalInteger64(values[0])
+	if err != nil {
+		return 0, false, err
+	}
+	return bitrate, true, nil
+}
+
+// SetBitrate sets the value of the EXT-X-BITRATE tag in kilobits per second.
+func (tags SegmentTags) SetBitrate(kbps int64) {
+	tags[TagExtXBitrate] = []string{strconv.FormatInt(kbps, 10)}
+}
+
+// ByteRange represents a sub-range of a resource.
+type ByteRange struct {
+	// Length is the length of the sub-range in bytes.
+	Length int64
+
+	// Offset is the start of the sub-range, as a byte offset from the beginning of the resource.
+	// This field is valid only when HasOffset is true.
+	Offset int64
+
+	// HasOffset indicates whether Offset is specified.
+	HasOffset bool
+}
+
+// ParseByteRange parses the byte range string in the form of <n>[@<o>].
+func ParseByteRange(byteRange string) (ByteRange, error) {
+	var br ByteRange
+	length := byteRange
+	if idx := strings.Index(byteRange, "@"); idx != -1 {
+		offset, err := strconv.ParseInt(byteRange[idx+1:], 10, 64)
+		if err != nil {
+			return ByteRange{}, err
+		}
+		if offset < 0 {
+			return ByteRange{}, errors.New("invalid byte range")
+		}
+		br.Offset = offset
+		br.HasOffset = true
+		length = byteRange[:idx]
+	}
+	var err error
+	br.Length, err = strconv.ParseInt(length, 10, 64)
+	if err != nil {
+		return ByteRange{}, err
+	}
+	if br.Length < 0 {
+		return ByteRange{}, errors.New("invalid byte range")
+	}
+	return br, nil
+}
+
+// String encodes the byte range to a string in the form of <n>[@<o>].
+func (br ByteRange) String() string {
+	if br.HasOffset {
+		return strconv.FormatInt(br.Length, 10) + "@" + strconv.FormatInt(br.Offset, 10)
+	}
+	return strconv.FormatInt(br.Length, 10)
+}
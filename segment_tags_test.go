@@ -32,6 +32,72 @@ func TestSegmentTags(t *testing.T) {
 		}, tags)
 	})
 
+	t.Run("byterange", func(t *testing.T) {
+		tags := make(SegmentTags)
+		byteRange, err := tags.ByteRange()
+		require.NoError(t, err)
+		assert.Nil(t, byteRange)
+		tags.SetByteRange(ByteRange{Length: 1000, Offset: 2000, HasOffset: true})
+		assert.Equal(t, SegmentTags{"EXT-X-BYTERANGE": []string{"1000@2000"}}, tags)
+		byteRange, err = tags.ByteRange()
+		require.NoError(t, err)
+		assert.Equal(t, &ByteRange{Length: 1000, Offset: 2000, HasOffset: true}, byteRange)
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		tags := make(SegmentTags)
+		assert.Empty(t, tags.Keys())
+		aes := make(KeyAttrs)
+		aes.SetMethod(KeyMethodAES128)
+		aes.SetURI("key1")
+		fairPlay := make(KeyAttrs)
+		fairPlay.SetMethod(KeyMethodSampleAES)
+		fairPlay.SetURI("skd://key1")
+		fairPlay.SetKeyFormat("com.apple.streamingkeydelivery")
+		tags.SetKeys([]KeyAttrs{aes, fairPlay})
+		assert.Equal(t, SegmentTags{"EXT-X-KEY": []string{
+			`METHOD=AES-128,URI="key1"`,
+			`KEYFORMAT="com.apple.streamingkeydelivery",METHOD=SAMPLE-AES,URI="skd://key1"`,
+		}}, tags)
+		assert.Equal(t, []KeyAttrs{aes, fairPlay}, tags.Keys())
+		tags.SetKeys(nil)
+		assert.Empty(t, tags)
+	})
+
+	t.Run("map", func(t *testing.T) {
+		tags := make(SegmentTags)
+		assert.Nil(t, tags.Map())
+		attrs := make(MapAttrs)
+		attrs.SetURI("init.mp4")
+		tags.SetMap(attrs)
+		assert.Equal(t, SegmentTags{"EXT-X-MAP": []string{`URI="init.mp4"`}}, tags)
+		assert.Equal(t, "init.mp4", tags.Map().URI())
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		tags := make(SegmentTags)
+		assert.False(t, tags.Gap())
+		tags.SetGap(true)
+		assert.Equal(t, SegmentTags{"EXT-X-GAP": []string{""}}, tags)
+		assert.True(t, tags.Gap())
+		tags.SetGap(false)
+		assert.Empty(t, tags)
+	})
+
+	t.Run("bitrate", func(t *testing.T) {
+		tags := make(SegmentTags)
+		_, ok := tags.Bitrate()
+		assert.False(t, ok)
+		tags.SetBitrate(1500)
+		assert.Equal(t, SegmentTags{"EXT-X-BITRATE": []string{"1500"}}, tags)
+		bitrate, ok := tags.Bitrate()
+		require.True(t, ok)
+		assert.Equal(t, int64(1500), bitrate)
+		tags["EXT-X-BITRATE"] = []string{"invalid"}
+		_, ok = tags.Bitrate()
+		assert.False(t, ok)
+	})
+
 	t.Run("pdt_not_found", func(t *testing.T) {
 		tags := SegmentTags{
 			"EXTINF": []string{"12.34,"},
@@ -48,3 +114,32 @@ func TestSegmentTags(t *testing.T) {
 		assert.False(t, ok)
 	})
 }
+
+func TestParseByteRange(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected ByteRange
+		valid    bool
+	}{
+		{input: "1000", expected: ByteRange{Length: 1000}, valid: true},
+		{input: "1000@2000", expected: ByteRange{Length: 1000, Offset: 2000, HasOffset: true}, valid: true},
+		{input: "1000@0", expected: ByteRange{Length: 1000, Offset: 0, HasOffset: true}, valid: true},
+		{input: ""},
+		{input: "abc"},
+		{input: "1000@"},
+		{input: "-1"},
+		{input: "1000@-1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			byteRange, err := ParseByteRange(tc.input)
+			if !tc.valid {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, byteRange)
+			assert.Equal(t, tc.input, byteRange.String())
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package m3u8
+
+import (
+	"math"
+	"sync"
+)
+
+// TagScope represents where a tag appears in a playlist.
+type TagScope int
+
+const (
+	// TagScopePlaylist represents a tag which applies to the whole playlist
+	// and can appear in both master and media playlists.
+	TagScopePlaylist TagScope = iota
+
+	// TagScopeMasterPlaylist represents a tag which appears only in master playlists.
+	TagScopeMasterPlaylist
+
+	// TagScopeMediaPlaylist represents a tag which applies to the whole media playlist.
+	TagScopeMediaPlaylist
+
+	// TagScopeSegment represents a tag which applies to a media segment.
+	TagScopeSegment
+)
+
+// String returns the name of the scope.
+func (scope TagScope) String() string {
+	switch scope {
+	case TagScopePlaylist:
+		return "playlist"
+	case TagScopeMasterPlaylist:
+		return "master playlist"
+	case TagScopeMediaPlaylist:
+		return "media playlist"
+	case TagScopeSegment:
+		return "segment"
+	}
+	return "unknown"
+}
+
+// TagDefinition represents how a tag is classified.
+type TagDefinition struct {
+	// Name is the name of the tag without the leading "#".
+	Name string
+
+	// Scope is where the tag appears.
+	Scope TagScope
+
+	// Order is the sort order of the tag, which Tags#List uses.
+	// Tags with smaller values are written first.
+	// Tags with the same value, such as unregistered tags, are written in the order
+	// in which they were decoded, or in alphabetical order of the names otherwise.
+	// Encode takes it from the registry which the playlist was decoded with.
+	Order int
+
+	// Repeatable reports whether the tag can appear more than once
+	// in the playlist header or in a media segment.
+	Repeatable bool
+}
+
+// TagRegistry is a set of tag definitions.
+// It is safe for concurrent use.
+type TagRegistry struct {
+	mu          sync.RWMutex
+	definitions map[string]TagDefinition
+}
+
+// DefaultTagRegistry is the registry used when DecodeOptions.TagRegistry is nil and by Tags#List.
+// Encode uses it for a playlist which is not decoded.
+// Register vendor tags to it to change how all decoders and encoders handle them.
+var DefaultTagRegistry = NewTagRegistry()
+
+// tagRegistryOrDefault returns the registry, or DefaultTagRegistry if it is nil.
+func tagRegistryOrDefault(registry *TagRegistry) *TagRegistry {
+	if registry != nil {
+		return registry
+	}
+	return DefaultTagRegistry
+}
+
+// NewTagRegistry creates a registry which contains the tags defined by RFC 8216
+// and the cue tags supported by this package.
+func NewTagRegistry() *TagRegistry {
+	registry := &TagRegistry{
+		definitions: make(map[string]TagDefinition, len(standardTagDefinitions)),
+	}
+	for _, def := range standardTagDefinitions {
+		registry.definitions[def.Name] = def
+	}
+	return registry
+}
+
+// Register adds the tag definition to the registry.
+// If the tag is already registered, the definition is overwritten.
+func (registry *TagRegistry) Register(def TagDefinition) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.definitions[def.Name] = def
+}
+
+// Unregister removes the definition of the tag from the registry.
+// Removing a standard tag makes the registry treat it as an unknown tag.
+func (registry *TagRegistry) Unregister(name string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	delete(registry.definitions, name)
+}
+
+// Lookup returns the definition of the tag.
+// If the tag is not registered, it returns false.
+func (registry *TagRegistry) Lookup(name string) (TagDefinition, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	def, ok := registry.definitions[name]
+	return def, ok
+}
+
+// Definition returns the definition of the tag.
+// An unregistered tag is treated as a repeatable playlist tag which is sorted
+// after all the registered tags. However, the media playlist decoder attaches
+// an unregistered tag which appears after the first segment has started to the segment.
+func (registry *TagRegistry) Definition(name string) TagDefinition {
+	if def, ok := registry.Lookup(name); ok {
+		return def
+	}
+	return TagDefinition{
+		Name:       name,
+		Scope:      TagScopePlaylist,
+		Order:      math.MaxInt,
+		Repeatable: true,
+	}
+}
+
+var standardTagDefinitions = []TagDefinition{
+	// Basic Tags
+	{Name: TagExtM3U, Scope: TagScopePlaylist, Order: -1},
+	{Name: TagExtXVersion, Scope: TagScopePlaylist, Order: 1},
+
+	// Media Playlist Tags
+	{Name: TagExtXTargetDuration, Scope: TagScopeMediaPlaylist, Order: 100},
+	{Name: TagExtXPlaylistType, Scope: TagScopeMediaPlaylist, Order: 101},
+	{Name: TagExtXIFramesOnly, Scope: TagScopeMediaPlaylist, Order: 102},
+	{Name: TagExtXMediaSequence, Scope: TagScopeMediaPlaylist, Order: 103},
+	{Name: TagExtXDiscontinuitySequence, Scope: TagScopeMediaPlaylist, Order: 104},
+	{Name: TagExtXServerControl, Scope: TagScopeMediaPlaylist, Order: 105},
+	{Name: TagExtXPartInf, Scope: TagScopeMediaPlaylist, Order: 106},
+	{Name: TagExtXPreloadHint, Scope: TagScopeMediaPlaylist, Order: math.MaxInt, Repeatable: true},
+	{Name: TagExtXRenditionReport, Scope: TagScopeMediaPlaylist, Order: math.MaxInt, Repeatable: true},
+	{Name: TagExtXEndlist, Scope: TagScopeMediaPlaylist, Order: math.MaxInt32},
+
+	// Media or Master Playlist Tags
+	{Name: TagExtXIndependentSegments, Scope: TagScopePlaylist, Order: 200},
+	{Name: TagExtXStart, Scope: TagScopePlaylist, Order: 201},
+
+	// Cue
+	{Name: TagExtXCueIn, Scope: TagScopeSegment, Order: 300, Repeatable: true},
+	{Name: TagExtOATCLSSCTE35, Scope: TagScopeSegment, Order: 301, Repeatable: true},
+	{Name: TagExtXAsset, Scope: TagScopeSegment, Order: 302, Repeatable: true},
+	{Name: TagExtXCueOut, Scope: TagScopeSegment, Order: 303, Repeatable: true},
+	{Name: TagExtXCueOutCont, Scope: TagScopeSegment, Order: 304, Repeatable: true},
+	{Name: TagExtXBlackout, Scope: TagScopeSegment, Order: 305, Repeatable: true},
+
+	// EXT-X-SKIP replaces the skipped segments,
+	// so it must follow all the other playlist tags.
+	{Name: TagExtXSkip, Scope: TagScopeMediaPlaylist, Order: 399},
+
+	// Segment Tags
+	{Name: TagExtXDiscontinuity, Scope: TagScopeSegment, Order: 400},
+	{Name: TagExtXKey, Scope: TagScopeSegment, Order: 401, Repeatable: true},
+	{Name: TagExtXMap, Scope: TagScopeSegment, Order: 402},
+	{Name: TagExtXProgramDateTime, Scope: TagScopeSegment, Order: 403},
+	{Name: TagExtXDateRange, Scope: TagScopeSegment, Order: 404, Repeatable: true},
+	{Name: TagExtXPart, Scope: TagScopeSegment, Order: 405, Repeatable: true},
+	{Name: TagExtInf, Scope: TagScopeSegment, Order: 406},
+	{Name: TagExtXByteRange, Scope: TagScopeSegment, Order: 407},
+	{Name: TagExtXBitrate, Scope: TagScopeSegment, Order: 408},
+	{Name: TagExtXGap, Scope: TagScopeSegment, Order: 409},
+
+	// Master Playlist Tags
+	{Name: TagExtXMedia, Scope: TagScopeMasterPlaylist, Order: 500, Repeatable: true},
+	{Name: TagExtXStreamInf, Scope: TagScopeMasterPlaylist, Order: 501, Repeatable: true},
+	{Name: TagExtXIFrameStreamInf, Scope: TagScopeMasterPlaylist, Order: 502, Repeatable: true},
+	{Name: TagExtXSessionData, Scope: TagScopeMasterPlaylist, Order: 503, Repeatable: true},
+	{Name: TagExtXSessionKey, Scope: TagScopeMasterPlaylist, Order: 504, Repeatable: true},
+}
@@ -0,0 +1,179 @@
+package m3u8
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagRegistry(t *testing.T) {
+	registry := NewTagRegistry()
+
+	def, ok := registry.Lookup(TagExtInf)
+	require.True(t, ok)
+	assert.Equal(t, TagDefinition{Name: TagExtInf, Scope: TagScopeSegment, Order: 406}, def)
+
+	_, ok = registry.Lookup("EXT-X-CUE-SPAN")
+	assert.False(t, ok)
+	assert.Equal(t, TagDefinition{
+		Name:       "EXT-X-CUE-SPAN",
+		Scope:      TagScopePlaylist,
+		Order:      math.MaxInt,
+		Repeatable: true,
+	}, registry.Definition("EXT-X-CUE-SPAN"))
+
+	registry.Register(TagDefinition{Name: "EXT-X-CUE-SPAN", Scope: TagScopeSegment, Order: 306})
+	def, ok = registry.Lookup("EXT-X-CUE-SPAN")
+	require.True(t, ok)
+	assert.Equal(t, TagScopeSegment, def.Scope)
+	_, ok = DefaultTagRegistry.Lookup("EXT-X-CUE-SPAN")
+	assert.False(t, ok)
+
+	registry.Unregister("EXT-X-CUE-SPAN")
+	_, ok = registry.Lookup("EXT-X-CUE-SPAN")
+	assert.False(t, ok)
+
+	assert.Equal(t, "segment", TagScopeSegment.String())
+	assert.Equal(t, "master playlist", TagScopeMasterPlaylist.String())
+}
+
+func TestDecodeWithTagRegistry(t *testing.T) {
+	input := "#EXTM3U\n" +
+		"#EXT-X-TARGETDURATION:6\n" +
+		"#EXTINF:6.000,\n" +
+		"seg0.ts\n" +
+		"#EXT-X-CUE-SPAN:TIMEFROMSIGNAL=PT2S\n" +
+		"#EXTINF:6.000,\n" +
+		"seg1.ts\n"
+	registry := NewTagRegistry()
+	registry.Register(TagDefinition{Name: "EXT-X-CUE-SPAN", Scope: TagScopeSegment, Order: 306})
+
+	t.Run("default", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		assert.NotContains(t, playlist.Tags, "EXT-X-CUE-SPAN")
+		assert.Equal(t, []string{"TIMEFROMSIGNAL=PT2S"}, playlist.Segments[1].Tags["EXT-X-CUE-SPAN"])
+
+		header := "#EXTM3U\n#EXT-X-CUE-SPAN:TIMEFROMSIGNAL=PT0S\n#EXT-X-TARGETDURATION:6\n"
+		playlist, err = DecodeMediaPlaylist(bytes.NewReader([]byte(header + input[len("#EXTM3U\n#EXT-X-TARGETDURATION:6\n"):])))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"TIMEFROMSIGNAL=PT0S"}, playlist.Tags["EXT-X-CUE-SPAN"])
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{TagRegistry: registry})
+		require.NoError(t, err)
+		assert.NotContains(t, playlist.Tags, "EXT-X-CUE-SPAN")
+		assert.Equal(t, []string{"TIMEFROMSIGNAL=PT2S"}, playlist.Segments[1].Tags["EXT-X-CUE-SPAN"])
+	})
+
+	t.Run("detection", func(t *testing.T) {
+		input := "#EXTM3U\n#EXT-X-VENDOR-SEGMENT:1\n#EXT-X-VENDOR-SEGMENT:2\n#EXT-X-SESSION-DATA:DATA-ID=\"a\",VALUE=\"b\"\nseg0.ts\n"
+		playlist, err := DecodePlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		assert.Equal(t, PlaylistTypeMaster, playlist.Type())
+
+		registry := NewTagRegistry()
+		registry.Register(TagDefinition{Name: "EXT-X-VENDOR-SEGMENT", Scope: TagScopeSegment, Repeatable: true})
+		playlist, err = DecodePlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{TagRegistry: registry})
+		require.NoError(t, err)
+		assert.Equal(t, PlaylistTypeMedia, playlist.Type())
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-TARGETDURATION:6\n" +
+			"#EXT-X-VENDOR-ONCE:1\n" +
+			"#EXT-X-VENDOR-ONCE:2\n" +
+			"#EXTINF:6.000,\n" +
+			"seg0.ts\n"
+		opts := &DecodeOptions{Mode: DecodeModeStrict, TagRegistry: NewTagRegistry()}
+		_, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), opts)
+		require.NoError(t, err)
+
+		opts.TagRegistry.Register(TagDefinition{Name: "EXT-X-VENDOR-ONCE", Scope: TagScopeMediaPlaylist})
+		_, err = DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), opts)
+		assert.ErrorIs(t, err, ErrDuplicateTag)
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, 4, decodeErr.Line)
+
+		_, err = DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte("#EXTM3U\n#EXTINF:6.000,\n#EXTINF:6.000,\nseg0.ts\n")), opts)
+		assert.ErrorIs(t, err, ErrDuplicateTag)
+
+		_, err = DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-VERSION:4\n")), opts)
+		assert.ErrorIs(t, err, ErrDuplicateTag)
+	})
+}
+
+func TestTagsListWithDefaultTagRegistry(t *testing.T) {
+	tags := Tags{
+		"EXT-X-VENDOR-FIRST": []string{"1"},
+		TagExtXVersion:       []string{"3"},
+	}
+	assert.Equal(t, []*Tag{
+		{Name: TagExtXVersion, Attributes: "3"},
+		{Name: "EXT-X-VENDOR-FIRST", Attributes: "1"},
+	}, tags.List())
+
+	DefaultTagRegistry.Register(TagDefinition{Name: "EXT-X-VENDOR-FIRST", Scope: TagScopePlaylist})
+	defer DefaultTagRegistry.Unregister("EXT-X-VENDOR-FIRST")
+	assert.Equal(t, []*Tag{
+		{Name: "EXT-X-VENDOR-FIRST", Attributes: "1"},
+		{Name: TagExtXVersion, Attributes: "3"},
+	}, tags.List())
+}
+
+func TestEncodeWithTagRegistry(t *testing.T) {
+	registry := NewTagRegistry()
+	registry.Register(TagDefinition{Name: "EXT-X-VENDOR-FIRST", Scope: TagScopePlaylist, Order: 0})
+
+	t.Run("media", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-VENDOR-FIRST:1\n" +
+			"#EXT-X-TARGETDURATION:6\n" +
+			"#EXTINF:6.000,\n" +
+			"seg0.ts\n"
+		playlist, err := DecodeMediaPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{TagRegistry: registry})
+		require.NoError(t, err)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+
+		playlist, err = DecodeMediaPlaylist(bytes.NewReader([]byte(input)))
+		require.NoError(t, err)
+		w = bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, "#EXTM3U\n"+
+			"#EXT-X-TARGETDURATION:6\n"+
+			"#EXT-X-VENDOR-FIRST:1\n"+
+			"#EXTINF:6.000,\n"+
+			"seg0.ts\n", w.String())
+	})
+
+	t.Run("master", func(t *testing.T) {
+		input := "#EXTM3U\n" +
+			"#EXT-X-VENDOR-FIRST:1\n" +
+			"#EXT-X-VERSION:3\n" +
+			"#EXT-X-STREAM-INF:BANDWIDTH=1280000\n" +
+			"low.m3u8\n"
+		playlist, err := DecodeMasterPlaylistWithOptions(bytes.NewReader([]byte(input)), &DecodeOptions{TagRegistry: registry})
+		require.NoError(t, err)
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, playlist.Encode(w))
+		assert.Equal(t, input, w.String())
+	})
+
+	t.Run("writer", func(t *testing.T) {
+		tags := make(MediaPlaylistTags)
+		tags.Raw().Add(&Tag{Name: TagExtM3U})
+		tags.Raw().Add(&Tag{Name: TagExtXTargetDuration, Attributes: "6"})
+		tags.Raw().Add(&Tag{Name: "EXT-X-VENDOR-FIRST", Attributes: "1"})
+		w := bytes.NewBuffer(nil)
+		require.NoError(t, NewMediaPlaylistWriterWithRegistry(w, registry).WriteHeader(tags, nil))
+		assert.Equal(t, "#EXTM3U\n#EXT-X-VENDOR-FIRST:1\n#EXT-X-TARGETDURATION:6\n", w.String())
+	})
+}
@@ -2,15 +2,16 @@ package m3u8
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"io"
-	"math"
-	"regexp"
 	"sort"
 	"strings"
 )
 
+// CommentMarker marks the position of a comment in the TagOrder fields.
+// A comment is a line which starts with "#" but not with "#EXT".
+const CommentMarker = "#"
+
 const (
 	// Basic Tags
 	TagExtM3U      = "EXTM3U"
@@ -30,6 +31,11 @@ const (
 	TagExtXEndlist               = "EXT-X-ENDLIST"
 	TagExtXPlaylistType          = "EXT-X-PLAYLIST-TYPE"
 	TagExtXIFramesOnly           = "EXT-X-I-FRAMES-ONLY"
+	TagExtXPreloadHint           = "EXT-X-PRELOAD-HINT"
+	TagExtXServerControl         = "EXT-X-SERVER-CONTROL"
+	TagExtXPartInf               = "EXT-X-PART-INF"
+	TagExtXSkip                  = "EXT-X-SKIP"
+	TagExtXRenditionReport       = "EXT-X-RENDITION-REPORT"
 
 	// Media or Master Playlist Tags
 	TagExtXIndependentSegments = "EXT-X-INDEPENDENT-SEGMENTS"
@@ -43,6 +49,9 @@ const (
 	TagExtXMap             = "EXT-X-MAP"
 	TagExtXProgramDateTime = "EXT-X-PROGRAM-DATE-TIME"
 	TagExtXDateRange       = "EXT-X-DATERANGE"
+	TagExtXPart            = "EXT-X-PART"
+	TagExtXGap             = "EXT-X-GAP"
+	TagExtXBitrate         = "EXT-X-BITRATE"
 
 	// Cue
 	TagExtOATCLSSCTE35 = "EXT-OATCLS-SCTE35"
@@ -54,86 +63,11 @@ const (
 	TagExtXBlackout    = "EXT-X-BLACKOUT"
 )
 
-var tagOrderMap = map[string]int{
-	// Basic Tags
-	TagExtM3U:      0,
-	TagExtXVersion: 1,
-
-	// Media Playlist Tags
-	TagExtXTargetDuration:        100,
-	TagExtXPlaylistType:          101,
-	TagExtXIFramesOnly:           102,
-	TagExtXMediaSequence:         103,
-	TagExtXDiscontinuitySequence: 104,
-	TagExtXEndlist:               math.MaxInt32,
-
-	// Media or Master Playlist Tags
-	TagExtXIndependentSegments: 200,
-	TagExtXStart:               201,
-
-	// Cue
-	TagExtXCueIn:       300,
-	TagExtOATCLSSCTE35: 301,
-	TagExtXAsset:       302,
-	TagExtXCueOut:      303,
-	TagExtXCueOutCont:  304,
-	TagExtXBlackout:    305,
-
-	// Segment Tags
-	TagExtXDiscontinuity:   400,
-	TagExtXKey:             401,
-	TagExtXMap:             402,
-	TagExtXProgramDateTime: 403,
-	TagExtXDateRange:       404,
-	TagExtInf:              405,
-	TagExtXByteRange:       406,
-
-	// Master Playlist Tags
-	TagExtXMedia:           500,
-	TagExtXStreamInf:       501,
-	TagExtXIFrameStreamInf: 502,
-	TagExtXSessionData:     503,
-	TagExtXSessionKey:      504,
-}
-
-func getTagOrder(name string) int {
-	if order, ok := tagOrderMap[name]; ok {
-		return order
+func getTagOrder(registry *TagRegistry, name string) int {
+	if name == CommentMarker {
+		return 0
 	}
-	return math.MaxInt
-}
-
-var masterPlaylistTagSet = map[string]struct{}{
-	TagExtXMedia:           {},
-	TagExtXStreamInf:       {},
-	TagExtXIFrameStreamInf: {},
-	TagExtXSessionData:     {},
-	TagExtXSessionKey:      {},
-}
-
-var mediaPlaylistTagSet = map[string]struct{}{
-	TagExtXTargetDuration:        {},
-	TagExtXPlaylistType:          {},
-	TagExtXIFramesOnly:           {},
-	TagExtXMediaSequence:         {},
-	TagExtXDiscontinuitySequence: {},
-	TagExtXEndlist:               {},
-}
-
-var segmentTagSet = map[string]struct{}{
-	TagExtInf:              {},
-	TagExtXByteRange:       {},
-	TagExtXDiscontinuity:   {},
-	TagExtXKey:             {},
-	TagExtXMap:             {},
-	TagExtXProgramDateTime: {},
-	TagExtXDateRange:       {},
-	TagExtOATCLSSCTE35:     {},
-	TagExtXAsset:           {},
-	TagExtXCueOut:          {},
-	TagExtXCueOutCont:      {},
-	TagExtXCueIn:           {},
-	TagExtXBlackout:        {},
+	return registry.Definition(name).Order
 }
 
 // TagName extracts the tag name from the line.
@@ -161,20 +95,9 @@ func AttributeString(line string) string {
 	return line[idx+1:]
 }
 
-func isMasterPlaylistTag(name string) bool {
-	_, ok := masterPlaylistTagSet[name]
-	return ok
-}
-
-func isMediaPlaylistTag(name string) bool {
-	_, ok := mediaPlaylistTagSet[name]
-	return ok
-}
-
-// IsSegmentTagName returns true if the name is a segment tag name.
+// IsSegmentTagName returns true if the name is a segment tag name in DefaultTagRegistry.
 func IsSegmentTagName(name string) bool {
-	_, ok := segmentTagSet[name]
-	return ok
+	return DefaultTagRegistry.Definition(name).Scope == TagScopeSegment
 }
 
 // Attributes represents a set of attributes of a tag.
@@ -202,20 +125,135 @@ func (attr Attributes) String() string {
 	return buf.String()
 }
 
-var regexpFirstAttribute = regexp.MustCompile(`^([0-9A-Za-z-]+)(?:=("[^"]*"|[^",]*)|)(?:,|$)`)
+// StringInOrder encodes the attributes to a string in the order of the keys.
+// The keys which are not included in order are written after them in alphabetical order.
+// To place newly added keys, append them or a canonical order of keys to order.
+func (attr Attributes) StringInOrder(order []string) string {
+	var buf bytes.Buffer
+	written := make(map[string]struct{}, len(attr))
+	write := func(key string) {
+		value, ok := attr[key]
+		if !ok {
+			return
+		}
+		if _, ok := written[key]; ok {
+			return
+		}
+		written[key] = struct{}{}
+		if buf.Len() != 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(key)
+		if value != "" {
+			buf.WriteString("=")
+			buf.WriteString(value)
+		}
+	}
+	for _, key := range order {
+		write(key)
+	}
+	if len(written) == len(attr) {
+		return buf.String()
+	}
+	keys := make([]string, 0, len(attr)-len(written))
+	for key := range attr {
+		if _, ok := written[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		write(key)
+	}
+	return buf.String()
+}
+
+// encodeAttributes encodes the attributes in the order if it is not nil,
+// otherwise in alphabetical order.
+func encodeAttributes(attr Attributes, order []string) string {
+	if order != nil {
+		return attr.StringInOrder(order)
+	}
+	return attr.String()
+}
 
 // ParseTagAttributes parses the attributes and returns it as Attributes.
 func ParseTagAttributes(attributes string) (Attributes, error) {
-	m := make(Attributes)
-	for len(attributes) != 0 {
-		s := regexpFirstAttribute.FindStringSubmatch(attributes)
-		if len(s) != 3 {
-			return nil, errors.New("invalid HLS tag attributes")
+	m, _, err := parseTagAttributes(attributes, false)
+	return m, err
+}
+
+// ParseTagAttributesInOrder parses the attributes and returns it as Attributes
+// along with the keys in the original order.
+func ParseTagAttributesInOrder(attributes string) (Attributes, []string, error) {
+	return parseTagAttributes(attributes, true)
+}
+
+func parseTagAttributes(attributes string, withOrder bool) (Attributes, []string, error) {
+	n := 0
+	if len(attributes) != 0 {
+		n = strings.Count(attributes, ",") + 1
+	}
+	m := make(Attributes, n)
+	var order []string
+	if withOrder {
+		order = make([]string, 0, n)
+	}
+	for i := 0; i < len(attributes); {
+		key, value, next, ok := parseFirstAttribute(attributes, i)
+		if !ok {
+			return nil, nil, &DecodeError{Raw: attributes[i:], Err: ErrInvalidAttributes}
+		}
+		m[key] = value
+		if withOrder {
+			order = append(order, key)
 		}
-		m[s[1]] = s[2]
-		attributes = attributes[len(s[0]):]
+		i = next
+	}
+	return m, order, nil
+}
+
+// parseFirstAttribute parses the attribute which starts at attributes[i].
+// It returns the key, the value and the index of the next attribute.
+// The key consists of any characters except '=', ',', '"', spaces and control characters,
+// which is a superset of the AttributeName of RFC 8216 to accept client attributes.
+// The value is a quoted string including the quotes, or a string without '"' and ','.
+func parseFirstAttribute(attributes string, i int) (key string, value string, next int, ok bool) {
+	start := i
+	for i < len(attributes) && isAttributeNameChar(attributes[i]) {
+		i++
+	}
+	if i == start {
+		return "", "", 0, false
+	}
+	key = attributes[start:i]
+	if i < len(attributes) && attributes[i] == '=' {
+		i++
+		start = i
+		if i < len(attributes) && attributes[i] == '"' {
+			end := strings.IndexByte(attributes[i+1:], '"')
+			if end == -1 {
+				return "", "", 0, false
+			}
+			i += end + 2
+		} else {
+			for i < len(attributes) && attributes[i] != ',' && attributes[i] != '"' {
+				i++
+			}
+		}
+		value = attributes[start:i]
+	}
+	if i == len(attributes) {
+		return key, value, i, true
 	}
-	return m, nil
+	if attributes[i] != ',' {
+		return "", "", 0, false
+	}
+	return key, value, i + 1, true
+}
+
+func isAttributeNameChar(c byte) bool {
+	return c > ' ' && c != 0x7f && c != '=' && c != ',' && c != '"'
 }
 
 // Tag represents a tag.
@@ -284,8 +322,58 @@ func (tags Tags) Remove(name string) {
 	delete(tags, name)
 }
 
-// List returns the sorted list of tags.
+// Clone returns a copy of the tags.
+func (tags Tags) Clone() Tags {
+	cloned := make(Tags, len(tags))
+	for name, attrsList := range tags {
+		cloned[name] = append([]string(nil), attrsList...)
+	}
+	return cloned
+}
+
+// withComments returns a copy of the tags which includes the comments under CommentMarker.
+// Comments are sorted right after EXTM3U unless the order says otherwise.
+func (tags Tags) withComments(comments []string) Tags {
+	if len(comments) == 0 {
+		return tags
+	}
+	cloned := tags.Clone()
+	cloned[CommentMarker] = append(cloned[CommentMarker], comments...)
+	return cloned
+}
+
+// encodeTagList writes the tags, including the comments made by Tags#withComments.
+func encodeTagList(w io.Writer, list []*Tag) error {
+	for _, tag := range list {
+		if tag.Name == CommentMarker {
+			if err := encodeComments(w, []string{tag.Attributes}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := tag.Encode(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeComments(w io.Writer, comments []string) error {
+	for _, comment := range comments {
+		if _, err := fmt.Fprintf(w, "#%s\n", comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the list of tags sorted by the order in DefaultTagRegistry.
 func (tags Tags) List() []*Tag {
+	return tags.list(DefaultTagRegistry)
+}
+
+// list returns the list of tags sorted by the order in the registry.
+func (tags Tags) list(registry *TagRegistry) []*Tag {
 	list := make([]*Tag, 0, len(tags))
 	for name, attrsList := range tags {
 		for _, attrs := range attrsList {
@@ -295,8 +383,140 @@ func (tags Tags) List() []*Tag {
 			})
 		}
 	}
+	sortTags(registry, list, nil)
+	return list
+}
+
+// ListInOrder returns the list of tags in the order of the names.
+// Each occurrence of a name in order takes the next tag of the name.
+// The tags which are not taken are merged into the list in the same order as List.
+func (tags Tags) ListInOrder(order []string) []*Tag {
+	return tags.listInOrder(DefaultTagRegistry, order)
+}
+
+// listInOrder is the same as ListInOrder except that it uses the order in the registry.
+func (tags Tags) listInOrder(registry *TagRegistry, order []string) []*Tag {
+	used := make(map[string]int, len(tags))
+	ordered := make([]*Tag, 0, len(order))
+	for _, name := range order {
+		idx := used[name]
+		if idx >= len(tags[name]) {
+			continue
+		}
+		ordered = append(ordered, &Tag{
+			Name:       name,
+			Attributes: tags[name][idx],
+		})
+		used[name] = idx + 1
+	}
+	rest := make([]*Tag, 0)
+	for name, attrsList := range tags {
+		for _, attrs := range attrsList[used[name]:] {
+			rest = append(rest, &Tag{
+				Name:       name,
+				Attributes: attrs,
+			})
+		}
+	}
+	if len(rest) == 0 {
+		return ordered
+	}
+	sortTags(registry, rest, nil)
+	list := make([]*Tag, 0, len(ordered)+len(rest))
+	for len(ordered) != 0 && len(rest) != 0 {
+		if getTagOrder(registry, rest[0].Name) < getTagOrder(registry, ordered[0].Name) {
+			list = append(list, rest[0])
+			rest = rest[1:]
+		} else {
+			list = append(list, ordered[0])
+			ordered = ordered[1:]
+		}
+	}
+	list = append(list, ordered...)
+	return append(list, rest...)
+}
+
+// listAnchored returns the list of tags sorted by the order in the registry except for the floating tags.
+// positions is the order of the names in which the tags were read.
+// Each floating tag is placed right before the tag which followed it in positions,
+// so that it stays among the same tags even if the other tags are sorted.
+// The floating tags which are not in positions are sorted with the others.
+// The tags of the same order keep the order in positions.
+func (tags Tags) listAnchored(registry *TagRegistry, positions []string, floating func(name string) bool) []*Tag {
+	type anchor struct {
+		name  string
+		index int
+	}
+	used := make(map[string]int, len(tags))
+	seen := make(map[string]int, len(tags))
+	anchored := make(map[anchor][]*Tag)
+	pending := make([]*Tag, 0)
+	for _, name := range positions {
+		if floating(name) {
+			idx := used[name]
+			if idx < len(tags[name]) {
+				pending = append(pending, &Tag{
+					Name:       name,
+					Attributes: tags[name][idx],
+				})
+				used[name] = idx + 1
+			}
+			continue
+		}
+		key := anchor{name: name, index: seen[name]}
+		seen[name]++
+		// If the tag has been removed, the floating tags are anchored to the next tag instead.
+		if len(pending) != 0 && key.index < len(tags[name]) {
+			anchored[key] = pending
+			pending = make([]*Tag, 0)
+		}
+	}
+	rest := make([]*Tag, 0, len(tags))
+	for name, attrsList := range tags {
+		for _, attrs := range attrsList[used[name]:] {
+			rest = append(rest, &Tag{
+				Name:       name,
+				Attributes: attrs,
+			})
+		}
+	}
+	sortTags(registry, rest, positions)
+	list := make([]*Tag, 0, len(rest)+len(positions))
+	occurrences := make(map[string]int, len(tags))
+	for _, tag := range rest {
+		if !floating(tag.Name) {
+			key := anchor{name: tag.Name, index: occurrences[tag.Name]}
+			occurrences[tag.Name]++
+			list = append(list, anchored[key]...)
+		}
+		list = append(list, tag)
+	}
+	return append(list, pending...)
+}
+
+// sortTags sorts the tags by the order in the registry.
+// The tags of the same order are sorted by their first occurrences in positions,
+// and then by name, so that the result does not depend on the iteration order of Tags.
+// The tags of the same name keep their relative order.
+func sortTags(registry *TagRegistry, list []*Tag, positions []string) {
+	first := make(map[string]int, len(positions))
+	for i, name := range positions {
+		if _, ok := first[name]; !ok {
+			first[name] = i
+		}
+	}
 	sort.SliceStable(list, func(i, j int) bool {
-		return getTagOrder(list[i].Name) < getTagOrder(list[j].Name)
+		iOrder, jOrder := getTagOrder(registry, list[i].Name), getTagOrder(registry, list[j].Name)
+		if iOrder != jOrder {
+			return iOrder < jOrder
+		}
+		iPos, iOK := first[list[i].Name]
+		jPos, jOK := first[list[j].Name]
+		if iOK != jOK {
+			return iOK
+		} else if iOK && iPos != jPos {
+			return iPos < jPos
+		}
+		return list[i].Name < list[j].Name
 	})
-	return list
 }
@@ -1,6 +1,7 @@
 package m3u8
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,6 +17,17 @@ func TestAttributes(t *testing.T) {
 		}
 		assert.Equal(t, `NO-VALUE,NUMBER=12345,STR="abcde"`, attr.String())
 	})
+
+	t.Run("StringInOrder", func(t *testing.T) {
+		attr := Attributes{
+			"NUMBER":   "12345",
+			"STR":      `"abcde"`,
+			"NO-VALUE": "",
+			"ADDED":    "1",
+		}
+		assert.Equal(t, `STR="abcde",NUMBER=12345,ADDED=1,NO-VALUE`, attr.StringInOrder([]string{"STR", "MISSING", "NUMBER", "STR"}))
+		assert.Equal(t, `ADDED=1,NO-VALUE,NUMBER=12345,STR="abcde"`, attr.StringInOrder(nil))
+	})
 }
 
 func TestParseTagAttributes(t *testing.T) {
@@ -52,6 +64,126 @@ func TestParseTagAttributes(t *testing.T) {
 	}
 }
 
+func TestParseTagAttributesNames(t *testing.T) {
+	m, err := ParseTagAttributes(`x-com.example_id="1",X-ÉVÉNEMENT=YES,BANDWIDTH=1280000`)
+	require.NoError(t, err)
+	assert.Equal(t, Attributes{
+		"x-com.example_id": `"1"`,
+		"X-ÉVÉNEMENT":      "YES",
+		"BANDWIDTH":        "1280000",
+	}, m)
+}
+
+func TestParseTagAttributesErrors(t *testing.T) {
+	inputs := []string{
+		`STR="foo`,
+		`STR="foo"bar`,
+		`STR=foo"bar"`,
+		`=foo`,
+		`,STR=foo`,
+		`STR=foo,,NUM=1`,
+		`S TR=foo`,
+		`"STR"=foo`,
+	}
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseTagAttributes(input)
+			assert.ErrorIs(t, err, ErrInvalidAttributes)
+		})
+	}
+}
+
+// regexpFirstAttribute is the regular expression used by the former parser.
+var regexpFirstAttribute = regexp.MustCompile(`^([0-9A-Za-z-]+)(?:=("[^"]*"|[^",]*)|)(?:,|$)`)
+
+func parseTagAttributesWithRegexp(attributes string) (Attributes, error) {
+	m := make(Attributes)
+	for len(attributes) != 0 {
+		s := regexpFirstAttribute.FindStringSubmatch(attributes)
+		if len(s) != 3 {
+			return nil, ErrInvalidAttributes
+		}
+		m[s[1]] = s[2]
+		attributes = attributes[len(s[0]):]
+	}
+	return m, nil
+}
+
+func TestParseTagAttributesCompatibility(t *testing.T) {
+	inputs := []string{
+		"",
+		"A",
+		"A=",
+		"A=,B",
+		"A=1,",
+		`A="",B=""`,
+		`A="a,b",B=c`,
+		`A="a"b`,
+		`A=a"b"`,
+		`A="a`,
+		`A=1,A=2`,
+		",",
+		"A,,B",
+		`BANDWIDTH=1280000,AVERAGE-BANDWIDTH=1000000,CODECS="avc1.4d401f,mp4a.40.2",RESOLUTION=1280x720,FRAME-RATE=29.970`,
+		`ID="ad-1",START-DATE="2020-01-02T03:04:05.678Z",PLANNED-DURATION=30.0,SCTE35-OUT=0xFC30`,
+	}
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			expected, expectedErr := parseTagAttributesWithRegexp(input)
+			m, err := ParseTagAttributes(input)
+			if expectedErr != nil {
+				assert.ErrorIs(t, err, ErrInvalidAttributes)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, expected, m)
+		})
+	}
+}
+
+var benchmarkAttributes = []string{
+	`BANDWIDTH=1280000,AVERAGE-BANDWIDTH=1000000,CODECS="avc1.4d401f,mp4a.40.2",RESOLUTION=1280x720,FRAME-RATE=29.970,AUDIO="aac"`,
+	`ID="ad-1",CLASS="com.example.ad",START-DATE="2020-01-02T03:04:05.678Z",PLANNED-DURATION=30.0,SCTE35-OUT=0xFC30,X-COM-EXAMPLE-AD-ID="XYZ123"`,
+}
+
+func BenchmarkParseTagAttributes(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, attributes := range benchmarkAttributes {
+			if _, err := ParseTagAttributes(attributes); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkParseTagAttributesWithRegexp(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, attributes := range benchmarkAttributes {
+			if _, err := parseTagAttributesWithRegexp(attributes); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestParseTagAttributesInOrder(t *testing.T) {
+	m, order, err := ParseTagAttributesInOrder(`STR="foo",HEX1=0x12ab,NO-VALUE`)
+	require.NoError(t, err)
+	assert.Equal(t, Attributes{"STR": `"foo"`, "HEX1": "0x12ab", "NO-VALUE": ""}, m)
+	assert.Equal(t, []string{"STR", "HEX1", "NO-VALUE"}, order)
+	assert.Equal(t, `STR="foo",HEX1=0x12ab,NO-VALUE`, m.StringInOrder(order))
+
+	m, order, err = ParseTagAttributesInOrder("")
+	require.NoError(t, err)
+	assert.Empty(t, m)
+	assert.NotNil(t, order)
+
+	_, _, err = ParseTagAttributesInOrder(`STR="foo`)
+	require.Error(t, err)
+}
+
 func TestTags(t *testing.T) {
 	tags := Tags{
 		"EXT-X-FOO": []string{"foo"},
@@ -71,4 +203,71 @@ func TestTags(t *testing.T) {
 	assert.Equal(t, &Tag{Name: "EXT-X-BAR", Attributes: "bar2"}, tags.Last("EXT-X-BAR"))
 	tags.Set(&Tag{Name: "EXT-X-BAR", Attributes: "bar3"})
 	assert.Equal(t, Tags{"EXT-X-BAR": []string{"bar3"}}, tags)
+	cloned := tags.Clone()
+	cloned.Add(&Tag{Name: "EXT-X-BAR", Attributes: "bar4"})
+	assert.Equal(t, Tags{"EXT-X-BAR": []string{"bar3"}}, tags)
+	assert.Equal(t, Tags{"EXT-X-BAR": []string{"bar3", "bar4"}}, cloned)
+}
+
+func TestTagsListEqualOrders(t *testing.T) {
+	tags := Tags{
+		"EXT-X-FOO":            []string{"1", "2"},
+		"EXT-X-BAR":            []string{"1"},
+		"EXT-X-BAZ":            []string{"1"},
+		TagExtXTargetDuration:  []string{"6"},
+		TagExtXRenditionReport: []string{`URI="a"`},
+		TagExtXPreloadHint:     []string{`URI="b"`},
+	}
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, []*Tag{
+			{Name: TagExtXTargetDuration, Attributes: "6"},
+			{Name: "EXT-X-BAR", Attributes: "1"},
+			{Name: "EXT-X-BAZ", Attributes: "1"},
+			{Name: "EXT-X-FOO", Attributes: "1"},
+			{Name: "EXT-X-FOO", Attributes: "2"},
+			{Name: TagExtXPreloadHint, Attributes: `URI="b"`},
+			{Name: TagExtXRenditionReport, Attributes: `URI="a"`},
+		}, tags.List())
+	}
+}
+
+func TestTagsListInOrder(t *testing.T) {
+	tags := Tags{
+		"EXTM3U":                  []string{""},
+		"EXT-X-VERSION":           []string{"3"},
+		"EXT-X-MEDIA-SEQUENCE":    []string{"100"},
+		"EXT-X-TARGETDURATION":    []string{"10"},
+		"EXT-X-VENDOR":            []string{"a", "b"},
+		"EXT-X-PROGRAM-DATE-TIME": []string{"2024-01-01T00:00:00Z"},
+	}
+
+	t.Run("all_ordered", func(t *testing.T) {
+		list := tags.ListInOrder([]string{"EXTM3U", "EXT-X-VENDOR", "EXT-X-MEDIA-SEQUENCE", "EXT-X-VERSION", "EXT-X-TARGETDURATION", "EXT-X-VENDOR", "EXT-X-PROGRAM-DATE-TIME"})
+		assert.Equal(t, []*Tag{
+			{Name: "EXTM3U", Attributes: ""},
+			{Name: "EXT-X-VENDOR", Attributes: "a"},
+			{Name: "EXT-X-MEDIA-SEQUENCE", Attributes: "100"},
+			{Name: "EXT-X-VERSION", Attributes: "3"},
+			{Name: "EXT-X-TARGETDURATION", Attributes: "10"},
+			{Name: "EXT-X-VENDOR", Attributes: "b"},
+			{Name: "EXT-X-PROGRAM-DATE-TIME", Attributes: "2024-01-01T00:00:00Z"},
+		}, list)
+	})
+
+	t.Run("partially_ordered", func(t *testing.T) {
+		list := tags.ListInOrder([]string{"EXTM3U", "EXT-X-MEDIA-SEQUENCE", "EXT-X-REMOVED", "EXT-X-VENDOR"})
+		assert.Equal(t, []*Tag{
+			{Name: "EXTM3U", Attributes: ""},
+			{Name: "EXT-X-VERSION", Attributes: "3"},
+			{Name: "EXT-X-TARGETDURATION", Attributes: "10"},
+			{Name: "EXT-X-MEDIA-SEQUENCE", Attributes: "100"},
+			{Name: "EXT-X-PROGRAM-DATE-TIME", Attributes: "2024-01-01T00:00:00Z"},
+			{Name: "EXT-X-VENDOR", Attributes: "a"},
+			{Name: "EXT-X-VENDOR", Attributes: "b"},
+		}, list)
+	})
+
+	t.Run("empty_order", func(t *testing.T) {
+		assert.Equal(t, tags.List(), tags.ListInOrder(nil))
+	})
 }